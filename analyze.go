@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vreid/shiki/internal/pkg/analysis"
+	"github.com/vreid/shiki/internal/pkg/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+func analyzeCycles(_ context.Context, cmd *cli.Command) error {
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var graph *analysis.PreferenceGraph
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			graph, err = analysis.LoadPreferenceGraph(tx, namespace)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to analyze cycles: %w", err)
+		}
+
+		report := graph.Cycles(cmd.Int("top"))
+
+		switch cmd.String("format") {
+		case "table":
+			printCycleReport(report)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(report)
+			if err != nil {
+				return fmt.Errorf("failed to encode cycle report: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		return nil
+	})
+}
+
+func printCycleReport(report analysis.CycleReport) {
+	_, _ = fmt.Fprintf(os.Stdout, "Assets:\t\t%d\n", report.Assets)
+	_, _ = fmt.Fprintf(os.Stdout, "Edges:\t\t%d\n", report.Edges)
+	_, _ = fmt.Fprintf(os.Stdout, "Triads:\t\t%d\n", report.Triads)
+	_, _ = fmt.Fprintf(os.Stdout, "Cyclic triads:\t%d\n", report.CyclicTriads)
+	_, _ = fmt.Fprintf(os.Stdout, "Consistency:\t%.4f\n", report.Consistency)
+	_, _ = fmt.Fprintln(os.Stdout)
+
+	_, _ = fmt.Fprintf(os.Stdout, "Strongly-connected components: %d\n", len(report.Components))
+	_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+	for _, component := range report.Components {
+		_, _ = fmt.Fprintf(os.Stdout, "%d\t%s\n", len(component), strings.Join(component, " "))
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout)
+
+	_, _ = fmt.Fprintln(os.Stdout, "Most-violated triads")
+	_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+	for _, triad := range report.ViolatedTriads {
+		_, _ = fmt.Fprintf(os.Stdout, "%.2f\t%s > %s > %s > %s\n",
+			triad.Strength, triad.Assets[0], triad.Assets[1], triad.Assets[2], triad.Assets[0])
+	}
+}
+
+func analyzeBootstrap(ctx context.Context, cmd *cli.Command) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var games *analysis.Games
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			games, err = analysis.LoadGames(tx, namespace)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load games: %w", err)
+		}
+
+		report, err := games.Bootstrap(ctx, cmd.Int("replicates"), cmd.Uint64("seed"))
+		if err != nil {
+			return fmt.Errorf("failed to bootstrap ratings: %w", err)
+		}
+
+		top := cmd.Int("top")
+		if top > 0 && top < len(report.Intervals) {
+			report.Intervals = report.Intervals[:top]
+		}
+
+		switch cmd.String("format") {
+		case "table":
+			_, _ = fmt.Fprintf(os.Stdout, "Games: %d, replicates: %d\n", report.Games, report.Replicates)
+			_, _ = fmt.Fprintln(os.Stdout, "Asset ID\t\t\t\t\tRating\t95% CI\t\t\tRank\t95% CI")
+			_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+			for _, interval := range report.Intervals {
+				_, _ = fmt.Fprintf(os.Stdout, "%s\t%.2f\t%.2f - %.2f\t%d\t%d - %d\n",
+					interval.AssetID, interval.Rating, interval.RatingLow, interval.RatingHigh,
+					interval.Rank, interval.RankLow, interval.RankHigh)
+			}
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(report)
+			if err != nil {
+				return fmt.Errorf("failed to encode bootstrap report: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		return nil
+	})
+}
+
+func analyzeCrowd(_ context.Context, cmd *cli.Command) error {
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var crowd *analysis.Crowd
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			crowd, err = analysis.LoadCrowd(tx, namespace)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load ballots: %w", err)
+		}
+
+		report := crowd.Fit()
+
+		top := cmd.Int("top")
+		if top > 0 && top < len(report.Ratings) {
+			report.Ratings = report.Ratings[:top]
+		}
+
+		switch cmd.String("format") {
+		case "table":
+			printCrowdReport(report)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(report)
+			if err != nil {
+				return fmt.Errorf("failed to encode crowd report: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		return nil
+	})
+}
+
+func printCrowdReport(report analysis.CrowdReport) {
+	_, _ = fmt.Fprintf(os.Stdout, "Outcomes:\t\t%d\n", report.Outcomes)
+	_, _ = fmt.Fprintf(os.Stdout, "Comparisons:\t\t%d\n", report.Comparisons)
+	_, _ = fmt.Fprintf(os.Stdout, "Iterations:\t\t%d\n", report.Iterations)
+	_, _ = fmt.Fprintf(os.Stdout, "Rank correlation:\t%.4f\n", report.RankCorrelation)
+	_, _ = fmt.Fprintln(os.Stdout)
+
+	_, _ = fmt.Fprintln(os.Stdout, "Asset ID\t\t\t\t\tRating\tRank\tElo\tElo rank")
+	_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+	for _, rating := range report.Ratings {
+		_, _ = fmt.Fprintf(os.Stdout, "%s\t%.2f\t%d\t%.2f\t%d\n",
+			rating.AssetID, rating.Rating, rating.Rank, rating.EloRating, rating.EloRank)
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout)
+
+	_, _ = fmt.Fprintln(os.Stdout, "Reliability\tComparisons\tVoter ID")
+	_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+	for _, voter := range report.Voters {
+		voterID := voter.VoterID
+		if voterID == "" {
+			voterID = "-"
+		}
+
+		_, _ = fmt.Fprintf(os.Stdout, "%.4f\t\t%d\t\t%s\n", voter.Reliability, voter.Comparisons, voterID)
+	}
+}
+
+func analyzePositions(_ context.Context, cmd *cli.Command) error {
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var report analysis.PositionReport
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			report, err = analysis.LoadPositionReport(tx, namespace)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to analyze position bias: %w", err)
+		}
+
+		switch cmd.String("format") {
+		case "table":
+			printPositionReport(report)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(report)
+			if err != nil {
+				return fmt.Errorf("failed to encode position report: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		return nil
+	})
+}
+
+func printPositionReport(report analysis.PositionReport) {
+	_, _ = fmt.Fprintf(os.Stdout, "Ballots:\t%d\n", report.Ballots)
+
+	for _, group := range report.Groups {
+		_, _ = fmt.Fprintln(os.Stdout)
+		_, _ = fmt.Fprintf(os.Stdout, "%d opponents: %d decisive votes, %d ties, chi-square %.2f with %d degrees of freedom\n",
+			group.Opponents, group.Votes, group.Ties, group.ChiSquare, group.DegreesOfFreedom)
+		_, _ = fmt.Fprintln(os.Stdout, "Slot\tWins\tShare\tOffset")
+		_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+		for slot, wins := range group.Wins {
+			_, _ = fmt.Fprintf(os.Stdout, "%d\t%d\t%.4f\t%+.2f\n", slot+1, wins, group.Shares[slot], group.Offsets[slot])
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vreid/shiki/internal/pkg/backup"
+	"github.com/vreid/shiki/internal/pkg/common"
+)
+
+var (
+	errMissingBackupFile = errors.New("a backup file is required")
+	errBackupRequest     = errors.New("backup request failed")
+)
+
+// backupDatabase writes a backup either downloaded from a running server or,
+// without --server, read from the local database or its snapshot. The plain
+// database is verified before it is compressed.
+//
+//nolint:cyclop // Both backup sources require this complexity
+func backupDatabase(ctx context.Context, cmd *cli.Command) error {
+	output := cmd.String("output")
+	compression := cmd.String("compression")
+
+	switch compression {
+	case backup.CompressionNone, backup.CompressionGzip:
+	default:
+		return fmt.Errorf("%w: %s", backup.ErrUnknownCompression, compression)
+	}
+
+	tmpPath := output + ".tmp"
+
+	//nolint:gosec
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	defer func() {
+		_ = dst.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	if len(cmd.String("server")) > 0 {
+		err = downloadBackup(ctx, cmd, dst)
+	} else {
+		err = withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+			return backup.Write(dbService.DB, dst, backup.CompressionNone)
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = dst.Sync()
+	if err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	err = backup.Verify(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if compression == backup.CompressionNone {
+		err = os.Rename(tmpPath, output)
+		if err != nil {
+			return fmt.Errorf("failed to move backup into place: %w", err)
+		}
+	} else {
+		err = compressBackup(tmpPath, output, compression)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Backup written to %s\n", output)
+
+	return nil
+}
+
+// downloadBackup writes the plain database of the server given by --server
+// to dst. It is transferred with the compression given by --compression.
+func downloadBackup(ctx context.Context, cmd *cli.Command, dst io.Writer) error {
+	backupURL, err := url.JoinPath(cmd.String("server"), "/admin/backup")
+	if err != nil {
+		return fmt.Errorf("invalid server URL: %w", err)
+	}
+
+	compression := url.QueryEscape(cmd.String("compression"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, backupURL+"?compression="+compression, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create backup request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cmd.String("token"))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request backup: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", errBackupRequest, resp.Status)
+	}
+
+	src, err := backup.NewReader(resp.Body)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	defer func() {
+		_ = src.Close()
+	}()
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return fmt.Errorf("failed to download backup: %w", err)
+	}
+
+	return nil
+}
+
+// compressBackup compresses the verified backup at srcPath to output.
+func compressBackup(srcPath, output, compression string) error {
+	//nolint:gosec
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+
+	defer func() {
+		_ = src.Close()
+	}()
+
+	tmpPath := output + ".tmp." + compression
+
+	//nolint:gosec
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed backup file: %w", err)
+	}
+
+	defer func() {
+		_ = dst.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	compressed, err := backup.NewWriter(dst, compression)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	_, err = io.Copy(compressed, src)
+	if err == nil {
+		err = compressed.Close()
+	}
+
+	if err == nil {
+		err = dst.Sync()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to compress backup: %w", err)
+	}
+
+	err = os.Rename(tmpPath, output)
+	if err != nil {
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+
+	return nil
+}
+
+func restoreDatabase(_ context.Context, cmd *cli.Command) error {
+	backupPath := cmd.Args().First()
+	if len(backupPath) == 0 {
+		return errMissingBackupFile
+	}
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+
+	defer func() {
+		_ = src.Close()
+	}()
+
+	previousPath, err := backup.Restore(cmd.String("data-dir"), src)
+	if err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Restored %s\n", backupPath)
+
+	if len(previousPath) > 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "Previous database moved to %s\n", previousPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/urfave/cli/v3"
+)
+
+var errAdminRequest = errors.New("admin request failed")
+
+// serverFlags point admin commands at a running server, which holds the
+// database open for writing.
+func serverFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "server",
+			Usage: "go through a running server, e.g. http://localhost:3000; without it the server must be stopped",
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			Usage:   "admin token or API key with the admin scope",
+			Sources: cli.EnvVars("SHIKI_ADMIN_TOKEN"),
+		},
+	}
+}
+
+// adminRequest calls the /admin API of the server given by --server, sending
+// body and decoding the response into result if they aren't nil.
+func adminRequest(ctx context.Context, cmd *cli.Command, method, path string, body, result any) error {
+	requestURL, err := url.JoinPath(cmd.String("server"), "/admin", path)
+	if err != nil {
+		return fmt.Errorf("invalid server URL: %w", err)
+	}
+
+	var reader io.Reader
+
+	if body != nil {
+		marshaled, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+
+		reader = bytes.NewReader(marshaled)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cmd.String("token"))
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		var httpErr struct {
+			Message string `json:"message"`
+		}
+
+		_ = json.NewDecoder(resp.Body).Decode(&httpErr)
+
+		return fmt.Errorf("%w: %s %s", errAdminRequest, resp.Status, httpErr.Message)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func contestFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "contest",
+		Value: contest.DefaultContestID,
+		Usage: "contest to read the ratings of",
+	}
+}
+
+func criterionFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "criterion",
+		Usage: "criterion of the contest to read the ratings of",
+	}
+}
+
+// loadNamespace returns the ratings namespace of the contest and criterion
+// selected with --contest and --criterion. The criteria of the default
+// contest are server flags and can't be checked here.
+func loadNamespace(tx *bolt.Tx, cmd *cli.Command) (string, error) {
+	contestID := cmd.String("contest")
+	criterionID := cmd.String("criterion")
+
+	if contestID == "" || contestID == contest.DefaultContestID {
+		return contest.CriterionNamespace("", criterionID), nil
+	}
+
+	result, err := contest.Load(tx, contestID)
+	if err != nil {
+		//nolint:wrapcheck
+		return "", err
+	}
+
+	if _, ok := result.Criterion(criterionID); criterionID != "" && !ok {
+		return "", fmt.Errorf("%w: %s", contest.ErrCriterionNotFound, criterionID)
+	}
+
+	return contest.CriterionNamespace(result.Namespace(), criterionID), nil
+}
+
+// readAssets reads asset IDs, one per line, from path or stdin for "-".
+func readAssets(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+
+	if path != "-" {
+		//nolint:gosec // Path is given by the operator
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open asset list: %w", err)
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		r = f
+	}
+
+	result := []string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		assetID := strings.TrimSpace(scanner.Text())
+		if assetID == "" || slices.Contains(result, assetID) {
+			continue
+		}
+
+		result = append(result, assetID)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset list: %w", err)
+	}
+
+	return result, nil
+}
+
+func createContest(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return errMissingContestID
+	}
+
+	assets, err := readAssets(cmd.String("assets"))
+	if err != nil {
+		return err
+	}
+
+	criteria, err := contest.ParseCriteria(cmd.StringSlice("criterion"))
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	newContest := contest.Contest{
+		ID:         cmd.Args().First(),
+		Name:       cmd.String("name"),
+		Assets:     assets,
+		Opponents:  cmd.Int("opponents"),
+		Difficulty: cmd.Int("difficulty"),
+		Criteria:   criteria,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if newContest.Name == "" {
+		newContest.Name = newContest.ID
+	}
+
+	if len(cmd.String("server")) > 0 {
+		err = adminRequest(ctx, cmd, http.MethodPost, "/contests", newContest, nil)
+	} else {
+		err = withDatabase(cmd, func(dbService *common.DatabaseService) error {
+			return dbService.DB.Update(func(tx *bolt.Tx) error {
+				return contest.Create(tx, newContest)
+			})
+		})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to create contest: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Created contest %s with %d assets\n", newContest.ID, len(newContest.Assets))
+
+	return nil
+}
+
+func listContests(_ context.Context, cmd *cli.Command) error {
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var contests []contest.Contest
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			var err error
+
+			contests, err = contest.List(tx)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list contests: %w", err)
+		}
+
+		summaries := make([]contest.Summary, 0, len(contests))
+		for _, c := range contests {
+			summaries = append(summaries, c.Summary())
+		}
+
+		switch cmd.String("format") {
+		case "table":
+			if len(summaries) == 0 {
+				_, _ = fmt.Fprintln(os.Stdout, "No contests found")
+
+				return nil
+			}
+
+			_, _ = fmt.Fprintln(os.Stdout, "ID\t\tAssets\tOpponents\tDifficulty\tCriteria\tName")
+			_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+			for _, summary := range summaries {
+				criteria := make([]string, 0, len(summary.Criteria))
+				for _, criterion := range summary.Criteria {
+					criteria = append(criteria, criterion.ID)
+				}
+
+				_, _ = fmt.Fprintf(os.Stdout, "%s\t\t%d\t%d\t\t%d\t\t%s\t\t%s\n",
+					summary.ID, summary.Assets, summary.Opponents, summary.Difficulty,
+					strings.Join(criteria, ","), summary.Name)
+			}
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(summaries)
+			if err != nil {
+				return fmt.Errorf("failed to encode contests: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		return nil
+	})
+}
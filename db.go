@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/integrity"
+	"github.com/vreid/shiki/internal/pkg/metadata"
+	bolt "go.etcd.io/bbolt"
+)
+
+var errIntegrityIssues = errors.New("database has integrity issues")
+
+func migrateDatabase(_ context.Context, cmd *cli.Command) error {
+	db, err := common.OpenDatabase(cmd.String("data-dir"))
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var version int64
+
+	_ = db.View(func(tx *bolt.Tx) error {
+		version = common.SchemaVersion(tx)
+
+		return nil
+	})
+
+	_, _ = fmt.Fprintf(os.Stdout, "Schema version: %d (latest %d)\n", version, common.LatestSchemaVersion())
+
+	dryRun := cmd.Bool("dry-run")
+
+	migrations, err := common.Migrate(db, dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	if len(migrations) == 0 {
+		_, _ = fmt.Fprintln(os.Stdout, "Database is up to date")
+
+		return nil
+	}
+
+	action := "Applied"
+	if dryRun {
+		action = "Pending"
+	}
+
+	for _, migration := range migrations {
+		_, _ = fmt.Fprintf(os.Stdout, "%s migration %d: %s\n", action, migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+func checkDatabase(_ context.Context, cmd *cli.Command) error {
+	options := integrity.Options{
+		KnownAssets: map[string][]string{"": metadata.Assets},
+		Repair:      cmd.Bool("repair"),
+		Tolerance:   cmd.Float64("tolerance"),
+	}
+
+	open, transaction := withReadOnlyDatabase, (*bolt.DB).View
+	if options.Repair {
+		open, transaction = withDatabase, (*bolt.DB).Update
+	}
+
+	return open(cmd, func(dbService *common.DatabaseService) error {
+		var report integrity.Report
+
+		err := transaction(dbService.DB, func(tx *bolt.Tx) error {
+			contests, err := contest.List(tx)
+			if err != nil {
+				return err
+			}
+
+			for _, c := range contests {
+				options.KnownAssets[c.Namespace()] = c.Assets
+
+				for _, criterion := range c.Criteria {
+					options.KnownAssets[contest.CriterionNamespace(c.Namespace(), criterion.ID)] = c.Assets
+				}
+			}
+
+			report, err = integrity.Check(tx, options)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check database: %w", err)
+		}
+
+		switch cmd.String("format") {
+		case "table":
+			printIntegrityReport(report)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(report)
+			if err != nil {
+				return fmt.Errorf("failed to encode integrity report: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		if unrepaired := report.Unrepaired(); unrepaired > 0 {
+			return fmt.Errorf("%w: %d unrepaired", errIntegrityIssues, unrepaired)
+		}
+
+		return nil
+	})
+}
+
+func printIntegrityReport(report integrity.Report) {
+	_, _ = fmt.Fprintln(os.Stdout, "Namespace\t\tAssets\tMean rating")
+	_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+	for _, namespace := range report.Namespaces {
+		name := namespace.Namespace
+		if name == "" || strings.HasPrefix(name, "#") {
+			name = contest.DefaultContestID + name
+		}
+
+		_, _ = fmt.Fprintf(os.Stdout, "%s\t\t%d\t%.2f\n", name, namespace.Assets, namespace.MeanRating)
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout)
+	_, _ = fmt.Fprintf(os.Stdout, "Issues:\t%d\n", len(report.Issues))
+
+	if len(report.Issues) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout)
+
+	for _, issue := range report.Issues {
+		status := ""
+		if issue.Repaired {
+			status = " (repaired)"
+		}
+
+		_, _ = fmt.Fprintf(os.Stdout, "%s\t%s\t%s%s\n", issue.Bucket, issue.Key, issue.Problem, status)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vreid/shiki/internal/pkg/admin"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/gold"
+	"github.com/vreid/shiki/internal/pkg/metadata"
+	bolt "go.etcd.io/bbolt"
+)
+
+// goldContest returns the contest a gold pair is added to. The default
+// contest is configured by server flags, so its opponents and criteria are
+// taken from the same flags and environment variables.
+func goldContest(tx *bolt.Tx, cmd *cli.Command) (contest.Contest, error) {
+	contestID := cmd.String("contest")
+
+	if contestID != "" && contestID != contest.DefaultContestID {
+		//nolint:wrapcheck
+		return contest.Load(tx, contestID)
+	}
+
+	criteria, err := contest.ParseCriteria(cmd.StringSlice("criteria"))
+	if err != nil {
+		//nolint:wrapcheck
+		return contest.Contest{}, err
+	}
+
+	return contest.Contest{
+		ID:        contest.DefaultContestID,
+		Assets:    metadata.Assets,
+		Opponents: cmd.Int("opponents"),
+		Criteria:  criteria,
+	}, nil
+}
+
+// goldPair builds the gold pair given on the command line.
+func goldPair(tx *bolt.Tx, cmd *cli.Command) (gold.Pair, error) {
+	current, err := goldContest(tx, cmd)
+	if err != nil {
+		return gold.Pair{}, err
+	}
+
+	//nolint:wrapcheck
+	return gold.NewContestPair(current, cmd.String("criterion"), cmd.Args().Slice(), cmd.String("winner"))
+}
+
+func addGold(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() == 0 {
+		return errMissingAssetID
+	}
+
+	var (
+		pair gold.Pair
+		err  error
+	)
+
+	if len(cmd.String("server")) > 0 {
+		request := admin.GoldRequest{
+			CriterionID: cmd.String("criterion"),
+			Assets:      cmd.Args().Slice(),
+			Winner:      cmd.String("winner"),
+		}
+
+		if contestID := cmd.String("contest"); contestID != contest.DefaultContestID {
+			request.ContestID = contestID
+		}
+
+		err = adminRequest(ctx, cmd, http.MethodPost, "/gold", request, &pair)
+	} else {
+		err = withDatabase(cmd, func(dbService *common.DatabaseService) error {
+			return dbService.DB.Update(func(tx *bolt.Tx) error {
+				var err error
+
+				pair, err = goldPair(tx, cmd)
+				if err != nil {
+					return err
+				}
+
+				pair.CreatedAt = time.Now().UTC()
+
+				return gold.Add(tx, pair)
+			})
+		})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to add gold pair: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Added gold pair %s\n", pair.ID)
+
+	return nil
+}
+
+func listGold(_ context.Context, cmd *cli.Command) error {
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var pairs []gold.Pair
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			var err error
+
+			pairs, err = gold.List(tx, nil)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list gold pairs: %w", err)
+		}
+
+		switch cmd.String("format") {
+		case "table":
+			if len(pairs) == 0 {
+				_, _ = fmt.Fprintln(os.Stdout, "No gold pairs found")
+
+				return nil
+			}
+
+			_, _ = fmt.Fprintln(os.Stdout, "ID\t\tNamespace\tWinner\t\tAssets")
+			_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+			for _, pair := range pairs {
+				namespace := pair.Namespace()
+				if pair.ContestID == "" {
+					namespace = contest.DefaultContestID + namespace
+				}
+
+				_, _ = fmt.Fprintf(os.Stdout, "%s\t%s\t\t%s\t\t%s\n",
+					pair.ID, namespace, pair.Winner, strings.Join(pair.Assets, ","))
+			}
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(pairs)
+			if err != nil {
+				return fmt.Errorf("failed to encode gold pairs: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		return nil
+	})
+}
+
+func removeGold(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return errMissingGoldID
+	}
+
+	var err error
+
+	if len(cmd.String("server")) > 0 {
+		err = adminRequest(ctx, cmd, http.MethodDelete, "/gold/"+url.PathEscape(cmd.Args().First()), nil, nil)
+	} else {
+		err = withDatabase(cmd, func(dbService *common.DatabaseService) error {
+			return dbService.DB.Update(func(tx *bolt.Tx) error {
+				_, err := gold.Remove(tx, cmd.Args().First())
+
+				return err
+			})
+		})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to remove gold pair: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Removed gold pair %s\n", cmd.Args().First())
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (s *AdminService) PostContest(c echo.Context) error {
+	var newContest contest.Contest
+
+	err := c.Bind(&newContest)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if newContest.Name == "" {
+		newContest.Name = newContest.ID
+	}
+
+	newContest.CreatedAt = time.Now().UTC()
+
+	err = contest.Validate(newContest)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = s.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		//nolint:wrapcheck
+		return contest.Create(tx, newContest)
+	})
+	if errors.Is(err, contest.ErrContestExists) {
+		return echo.NewHTTPError(http.StatusConflict, "contest already exists")
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create contest")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusCreated, newContest.Summary(), "  ")
+}
@@ -0,0 +1,44 @@
+package admin_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestPostContest(t *testing.T) {
+	t.Parallel()
+
+	adminService := newTestAdminService(t)
+
+	e := echo.New()
+	e.POST("/admin/contests", adminService.PostContest)
+
+	body := `{"id": "photos", "assets": ["a-1", "a-2", "a-3"], "opponents": 2}`
+
+	rec := serveJSON(e, http.MethodPost, "/admin/contests", body)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/admin/contests", body)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/admin/contests", `{"id": "default", "assets": ["a-1", "a-2"], "opponents": 2}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	err := adminService.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		photos, err := contest.Load(tx, "photos")
+		require.NoError(t, err)
+
+		assert.Equal(t, "photos", photos.Name)
+		assert.Len(t, photos.Assets, 3)
+		assert.False(t, photos.CreatedAt.IsZero())
+
+		return nil
+	})
+	require.NoError(t, err)
+}
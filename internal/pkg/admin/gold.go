@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/gold"
+	bolt "go.etcd.io/bbolt"
+)
+
+// GoldRequest is a gold pair to add. The empty contest ID refers to the
+// default contest.
+type GoldRequest struct {
+	ContestID   string   `json:"contest_id,omitempty"`
+	CriterionID string   `json:"criterion_id,omitempty"`
+	Assets      []string `json:"assets"`
+	Winner      string   `json:"winner"`
+}
+
+func (s *AdminService) PostGold(c echo.Context) error {
+	var request GoldRequest
+
+	err := c.Bind(&request)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	current, err := s.ContestService.Get(request.ContestID)
+	if errors.Is(err, contest.ErrContestNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "contest not found")
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load contest")
+	}
+
+	pair, err := gold.NewContestPair(current, request.CriterionID, request.Assets, request.Winner)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	pair.CreatedAt = time.Now().UTC()
+
+	err = s.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		//nolint:wrapcheck
+		return gold.Add(tx, pair)
+	})
+	if errors.Is(err, gold.ErrPairExists) {
+		return echo.NewHTTPError(http.StatusConflict, "gold pair already exists")
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to add gold pair")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusCreated, pair, "  ")
+}
+
+func (s *AdminService) DeleteGold(c echo.Context) error {
+	var pair gold.Pair
+
+	err := s.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		var err error
+
+		pair, err = gold.Remove(tx, c.Param("goldID"))
+
+		//nolint:wrapcheck
+		return err
+	})
+	if errors.Is(err, gold.ErrPairNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "gold pair not found")
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to remove gold pair")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, pair, "  ")
+}
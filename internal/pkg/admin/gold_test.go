@@ -0,0 +1,60 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/gold"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestGold(t *testing.T) {
+	t.Parallel()
+
+	adminService := newTestAdminService(t)
+
+	e := echo.New()
+	e.POST("/admin/gold", adminService.PostGold)
+	e.DELETE("/admin/gold/:goldID", adminService.DeleteGold)
+
+	body := `{"assets": ["a-1", "a-2"], "winner": "a-2"}`
+
+	rec := serveJSON(e, http.MethodPost, "/admin/gold", body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var pair gold.Pair
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pair))
+	assert.Empty(t, pair.ContestID)
+	assert.False(t, pair.CreatedAt.IsZero())
+
+	rec = serveJSON(e, http.MethodPost, "/admin/gold", body)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// The default contest has two opponents and only knows a-1 to a-3.
+	rec = serveJSON(e, http.MethodPost, "/admin/gold", `{"assets": ["a-1", "a-2", "a-3"], "winner": "a-1"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/admin/gold", `{"assets": ["a-1", "b-1"], "winner": "a-1"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/admin/gold", `{"contest_id": "photos", "assets": ["a-1", "a-2"], "winner": "a-1"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/admin/gold/"+pair.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/admin/gold/"+pair.ID, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, adminService.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		pairs, err := gold.List(tx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, pairs)
+
+		return nil
+	}))
+}
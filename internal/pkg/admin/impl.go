@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/backup"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+)
+
+type AdminService struct {
+	DatabaseService *common.DatabaseService
+
+	// ContestService knows the default contest, whose settings are only
+	// given to the server.
+	ContestService *contest.ContestService
+}
+
+func NewAdminService(i do.Injector) (*AdminService, error) {
+	databaseService := do.MustInvoke[*common.DatabaseService](i)
+	authService := do.MustInvoke[*auth.AuthService](i)
+	contestService := do.MustInvoke[*contest.ContestService](i)
+
+	result := &AdminService{
+		DatabaseService: databaseService,
+		ContestService:  contestService,
+	}
+
+	echoService, err := do.Invoke[*common.EchoService](i)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create echo service: %w", err)
+	}
+
+	echoService.Register(func(e *echo.Echo) {
+		adminGroup := e.Group("/admin", authService.Require(auth.ScopeAdmin))
+
+		adminGroup.GET("/backup", result.GetBackup)
+		adminGroup.GET("/voters", result.GetVoters)
+		adminGroup.GET("/voters/:voterID", result.GetVoter)
+		adminGroup.GET("/keys", result.GetKeys)
+		adminGroup.POST("/keys", result.PostKey)
+		adminGroup.DELETE("/keys/:keyID", result.DeleteKey)
+		adminGroup.POST("/contests", result.PostContest)
+		adminGroup.POST("/gold", result.PostGold)
+		adminGroup.DELETE("/gold/:goldID", result.DeleteGold)
+	})
+
+	return result, nil
+}
+
+func (s *AdminService) GetBackup(c echo.Context) error {
+	compression := c.QueryParam("compression")
+	if len(compression) == 0 {
+		compression = backup.CompressionNone
+	}
+
+	filename := fmt.Sprintf("shiki-%s.db", time.Now().UTC().Format("20060102T150405Z"))
+
+	switch compression {
+	case backup.CompressionNone:
+	case backup.CompressionGzip:
+		filename += ".gz"
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid compression value")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEOctetStream)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Response().WriteHeader(http.StatusOK)
+
+	//nolint:wrapcheck
+	return backup.Write(s.DatabaseService.DB, c.Response(), compression)
+}
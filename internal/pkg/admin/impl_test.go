@@ -0,0 +1,39 @@
+package admin_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	admin "github.com/vreid/shiki/internal/pkg/admin"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+)
+
+func newTestAdminService(tb testing.TB) *admin.AdminService {
+	tb.Helper()
+
+	db := testutil.OpenMigratedDatabase(tb)
+
+	databaseService := &common.DatabaseService{DB: db}
+
+	return &admin.AdminService{
+		DatabaseService: databaseService,
+		ContestService: &contest.ContestService{
+			DatabaseService: databaseService,
+			Default:         contest.Contest{ID: contest.DefaultContestID, Assets: []string{"a-1", "a-2", "a-3"}, Opponents: 2},
+		},
+	}
+}
+
+func serveJSON(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
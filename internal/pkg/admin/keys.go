@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	bolt "go.etcd.io/bbolt"
+)
+
+type KeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreatedKey is a new API key with its token, which is only returned once.
+type CreatedKey struct {
+	Key   auth.Key `json:"key"`
+	Token string   `json:"token"`
+}
+
+func (s *AdminService) PostKey(c echo.Context) error {
+	var request KeyRequest
+
+	err := c.Bind(&request)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if len(request.Name) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing name")
+	}
+
+	scopes, err := auth.ParseScopes(request.Scopes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	var result CreatedKey
+
+	err = s.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		var err error
+
+		result.Key, result.Token, err = auth.Create(tx, request.Name, scopes)
+
+		//nolint:wrapcheck
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create API key")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusCreated, result, "  ")
+}
+
+func (s *AdminService) GetKeys(c echo.Context) error {
+	var keys []auth.Key
+
+	err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		keys, err = auth.List(tx)
+
+		//nolint:wrapcheck
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list API keys")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, keys, "  ")
+}
+
+func (s *AdminService) DeleteKey(c echo.Context) error {
+	var key auth.Key
+
+	err := s.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		var err error
+
+		key, err = auth.Revoke(tx, c.Param("keyID"))
+
+		//nolint:wrapcheck
+		return err
+	})
+	if errors.Is(err, auth.ErrKeyNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "API key not found")
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke API key")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, key, "  ")
+}
@@ -0,0 +1,50 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admin "github.com/vreid/shiki/internal/pkg/admin"
+	"github.com/vreid/shiki/internal/pkg/auth"
+)
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+
+	adminService := newTestAdminService(t)
+
+	e := echo.New()
+	e.GET("/admin/keys", adminService.GetKeys)
+	e.POST("/admin/keys", adminService.PostKey)
+	e.DELETE("/admin/keys/:keyID", adminService.DeleteKey)
+
+	rec := serveJSON(e, http.MethodPost, "/admin/keys", `{"name": "uploader", "scopes": ["upload"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created admin.CreatedKey
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, []auth.Scope{auth.ScopeUpload}, created.Key.Scopes)
+	assert.True(t, strings.HasPrefix(created.Token, auth.TokenPrefix+"_"+created.Key.ID))
+
+	rec = serveJSON(e, http.MethodPost, "/admin/keys", `{"name": "uploader", "scopes": ["everything"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/admin/keys/"+created.Key.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveJSON(e, http.MethodGet, "/admin/keys", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var keys []auth.Key
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	assert.True(t, keys[0].Revoked())
+
+	rec = serveJSON(e, http.MethodDelete, "/admin/keys/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/voter"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DefaultVoterLimit = 50
+	MaxVoterLimit     = 1000
+)
+
+type VoterList struct {
+	Total  int             `json:"total"`
+	Voters []voter.Summary `json:"voters"`
+}
+
+// voterOrders compare summaries so that the most suspicious or active voters
+// come first.
+var voterOrders = map[string]func(a, b voter.Summary) int{
+	"votes": func(a, b voter.Summary) int {
+		return cmp.Compare(b.Votes, a.Votes)
+	},
+	"agreement": func(a, b voter.Summary) int {
+		return cmp.Compare(a.AgreementRate, b.AgreementRate)
+	},
+	"time": func(a, b voter.Summary) int {
+		return cmp.Compare(a.MeanTimeMs, b.MeanTimeMs)
+	},
+	"bias": func(a, b voter.Summary) int {
+		return cmp.Compare(max(b.FirstPositionBias, -b.FirstPositionBias), max(a.FirstPositionBias, -a.FirstPositionBias))
+	},
+}
+
+func (s *AdminService) GetVoters(c echo.Context) error {
+	limit, err := common.QueryInt(c, "limit", DefaultVoterLimit)
+	if err != nil {
+		return err
+	}
+
+	minVotes, err := common.QueryInt(c, "min_votes", 0)
+	if err != nil {
+		return err
+	}
+
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		sort = "votes"
+	}
+
+	order, ok := voterOrders[sort]
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid sort value")
+	}
+
+	var stats []voter.Stats
+
+	err = s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		stats, err = voter.List(tx)
+
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load voters")
+	}
+
+	summaries := make([]voter.Summary, 0, len(stats))
+
+	for _, voterStats := range stats {
+		if voterStats.Votes >= int64(minVotes) {
+			summaries = append(summaries, voterStats.Summary())
+		}
+	}
+
+	slices.SortStableFunc(summaries, order)
+
+	result := VoterList{
+		Total:  len(summaries),
+		Voters: summaries[:min(len(summaries), limit, MaxVoterLimit)],
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, result, "  ")
+}
+
+func (s *AdminService) GetVoter(c echo.Context) error {
+	var stats voter.Stats
+
+	err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		stats, err = voter.Load(tx, c.Param("voterID"))
+
+		return err
+	})
+	if errors.Is(err, voter.ErrVoterNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "voter not found")
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load voter")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, stats.Summary(), "  ")
+}
@@ -0,0 +1,292 @@
+package analysis
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// fitIterations bounds the minorization-maximization iterations of a fit.
+	fitIterations = 200
+	// fitTolerance stops a fit early once no strength moves by more than it.
+	fitTolerance = 1e-6
+	// priorGames is the weight of the virtual tie every asset plays against an
+	// average opponent, which keeps undefeated assets finite.
+	priorGames = 1.0
+)
+
+var ErrInvalidReplicates = errors.New("bootstrap needs at least one replicate")
+
+type game struct {
+	a, b int
+	// score is 1 if a won, 0 if b won and 0.5 for a tie.
+	score float64
+}
+
+// Games is the flat list of pairwise games reconstructed from the stored
+// head-to-head records.
+type Games struct {
+	assets []string
+	index  map[string]int
+	games  []game
+}
+
+func NewGames() *Games {
+	return &Games{
+		assets: []string{},
+		index:  map[string]int{},
+		games:  []game{},
+	}
+}
+
+// LoadGames reconstructs every pairwise game from the head-to-head records of
+// a ratings namespace.
+func LoadGames(tx *bolt.Tx, namespace string) (*Games, error) {
+	result := NewGames()
+
+	err := scorer.ForEachPairRecord(tx, namespace, func(assetID string, record scorer.PairRecord) error {
+		if assetID < record.OpponentAssetID {
+			result.AddPair(assetID, record)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load games: %w", err)
+	}
+
+	return result, nil
+}
+
+func (g *Games) AddPair(assetID string, record scorer.PairRecord) {
+	a := g.node(assetID)
+	b := g.node(record.OpponentAssetID)
+
+	for range record.Wins {
+		g.games = append(g.games, game{a: a, b: b, score: 1.0})
+	}
+
+	for range record.Losses {
+		g.games = append(g.games, game{a: a, b: b, score: 0.0})
+	}
+
+	for range record.Ties {
+		g.games = append(g.games, game{a: a, b: b, score: 0.5})
+	}
+}
+
+func (g *Games) node(assetID string) int {
+	idx, ok := g.index[assetID]
+	if ok {
+		return idx
+	}
+
+	idx = len(g.assets)
+
+	g.assets = append(g.assets, assetID)
+	g.index[assetID] = idx
+
+	return idx
+}
+
+// fit estimates Bradley-Terry strengths for the given games and returns them
+// on the Elo scale, centered on the default rating.
+func (g *Games) fit(games []game) []float64 {
+	n := len(g.assets)
+	if n == 0 {
+		return []float64{}
+	}
+
+	strengths := make([]float64, n)
+	for i := range strengths {
+		strengths[i] = 1.0
+	}
+
+	wins := make([]float64, n)
+
+	for _, gm := range games {
+		wins[gm.a] += gm.score
+		wins[gm.b] += 1.0 - gm.score
+	}
+
+	denominators := make([]float64, n)
+
+	for range fitIterations {
+		for i := range denominators {
+			denominators[i] = priorGames / (strengths[i] + 1.0)
+		}
+
+		for _, gm := range games {
+			d := 1.0 / (strengths[gm.a] + strengths[gm.b])
+			denominators[gm.a] += d
+			denominators[gm.b] += d
+		}
+
+		delta := 0.0
+		logSum := 0.0
+
+		for i := range strengths {
+			next := (wins[i] + priorGames/2.0) / denominators[i]
+			delta = max(delta, math.Abs(next-strengths[i]))
+			strengths[i] = next
+			logSum += math.Log(next)
+		}
+
+		// Keep the geometric mean at 1, so the average rating stays put.
+		scale := math.Exp(logSum / float64(n))
+		for i := range strengths {
+			strengths[i] /= scale
+		}
+
+		if delta < fitTolerance {
+			break
+		}
+	}
+
+	ratings := make([]float64, n)
+	for i, strength := range strengths {
+		ratings[i] = scorer.DefaultRating + 400.0*math.Log10(strength)
+	}
+
+	return ratings
+}
+
+// Bootstrap refits the ratings on replicates resamples of the games, spread
+// over every CPU core, and reports the 95% interval of each asset's rating and
+// rank. It stops early with an error when ctx is cancelled.
+//
+//nolint:funlen // Worker orchestration requires this length
+func (g *Games) Bootstrap(ctx context.Context, replicates int, seed uint64) (BootstrapReport, error) {
+	if replicates < 1 {
+		return BootstrapReport{}, fmt.Errorf("%w: %d", ErrInvalidReplicates, replicates)
+	}
+
+	n := len(g.assets)
+
+	point := g.fit(g.games)
+	pointRanks := ranks(point)
+
+	samples := make([][]float64, replicates)
+	sampleRanks := make([][]int, replicates)
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range runtime.NumCPU() {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			resampled := make([]game, len(g.games))
+
+			for replicate := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				rng := rand.New(rand.NewPCG(seed, uint64(replicate))) //nolint:gosec // Reproducible resampling
+
+				for i := range resampled {
+					resampled[i] = g.games[rng.IntN(len(g.games))]
+				}
+
+				samples[replicate] = g.fit(resampled)
+				sampleRanks[replicate] = ranks(samples[replicate])
+			}
+		}()
+	}
+
+	var err error
+
+	for replicate := range replicates {
+		select {
+		case jobs <- replicate:
+			continue
+		case <-ctx.Done():
+			err = fmt.Errorf("bootstrap cancelled: %w", ctx.Err())
+		}
+
+		break
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if err == nil && ctx.Err() != nil {
+		err = fmt.Errorf("bootstrap cancelled: %w", ctx.Err())
+	}
+
+	if err != nil {
+		return BootstrapReport{}, err
+	}
+
+	result := BootstrapReport{
+		Games:      len(g.games),
+		Replicates: replicates,
+		Intervals:  make([]Interval, 0, n),
+	}
+
+	for i, assetID := range g.assets {
+		ratings := make([]float64, 0, replicates)
+		assetRanks := make([]int, 0, replicates)
+
+		for replicate := range replicates {
+			ratings = append(ratings, samples[replicate][i])
+			assetRanks = append(assetRanks, sampleRanks[replicate][i])
+		}
+
+		slices.Sort(ratings)
+		slices.Sort(assetRanks)
+
+		result.Intervals = append(result.Intervals, Interval{
+			AssetID:    assetID,
+			Rating:     point[i],
+			RatingLow:  ratings[quantileIndex(replicates, 0.025)],
+			RatingHigh: ratings[quantileIndex(replicates, 0.975)],
+			Rank:       pointRanks[i],
+			RankLow:    assetRanks[quantileIndex(replicates, 0.025)],
+			RankHigh:   assetRanks[quantileIndex(replicates, 0.975)],
+		})
+	}
+
+	slices.SortFunc(result.Intervals, func(x, y Interval) int {
+		return cmp.Compare(x.Rank, y.Rank)
+	})
+
+	return result, nil
+}
+
+// ranks returns the 1-based rank of every rating, highest rating first.
+func ranks(ratings []float64) []int {
+	order := make([]int, len(ratings))
+	for i := range order {
+		order[i] = i
+	}
+
+	slices.SortStableFunc(order, func(x, y int) int {
+		return cmp.Compare(ratings[y], ratings[x])
+	})
+
+	result := make([]int, len(ratings))
+	for rank, i := range order {
+		result[i] = rank + 1
+	}
+
+	return result
+}
+
+func quantileIndex(n int, q float64) int {
+	return min(n-1, max(0, int(math.Round(q*float64(n-1)))))
+}
@@ -0,0 +1,73 @@
+package analysis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	analysis "github.com/vreid/shiki/internal/pkg/analysis"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+)
+
+func testGames() *analysis.Games {
+	games := analysis.NewGames()
+
+	games.AddPair("a", scorer.PairRecord{OpponentAssetID: "b", Wins: 30, Losses: 10})
+	games.AddPair("b", scorer.PairRecord{OpponentAssetID: "c", Wins: 30, Losses: 10})
+	games.AddPair("a", scorer.PairRecord{OpponentAssetID: "c", Wins: 35, Losses: 5, Ties: 2})
+
+	return games
+}
+
+func TestBootstrap(t *testing.T) {
+	t.Parallel()
+
+	report, err := testGames().Bootstrap(t.Context(), 100, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, 122, report.Games)
+	assert.Equal(t, 100, report.Replicates)
+	require.Len(t, report.Intervals, 3)
+
+	for idx, assetID := range []string{"a", "b", "c"} {
+		interval := report.Intervals[idx]
+
+		assert.Equal(t, assetID, interval.AssetID)
+		assert.Equal(t, idx+1, interval.Rank)
+		assert.LessOrEqual(t, interval.RatingLow, interval.Rating)
+		assert.GreaterOrEqual(t, interval.RatingHigh, interval.Rating)
+		assert.LessOrEqual(t, interval.RankLow, interval.Rank)
+		assert.GreaterOrEqual(t, interval.RankHigh, interval.Rank)
+	}
+
+	mean := 0.0
+	for _, interval := range report.Intervals {
+		mean += interval.Rating / 3.0
+	}
+
+	assert.InDelta(t, scorer.DefaultRating, mean, 0.0001)
+
+	again, err := testGames().Bootstrap(t.Context(), 100, 1)
+	require.NoError(t, err)
+	assert.Equal(t, report, again)
+}
+
+func TestBootstrapCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := testGames().Bootstrap(ctx, 100, 1)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBootstrapInvalidReplicates(t *testing.T) {
+	t.Parallel()
+
+	for _, replicates := range []int{0, -1} {
+		_, err := testGames().Bootstrap(t.Context(), replicates, 1)
+		require.ErrorIs(t, err, analysis.ErrInvalidReplicates)
+	}
+}
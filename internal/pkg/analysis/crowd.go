@@ -0,0 +1,258 @@
+package analysis
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// crowdIterations bounds the expectation-maximization rounds of a fit.
+	crowdIterations = 100
+	// crowdTolerance stops a fit early once no rating moves by more than it.
+	crowdTolerance = 1e-3
+	// reliabilityPriorMean and reliabilityPriorVotes make up the Beta prior of
+	// voter reliability: every voter starts out with reliabilityPriorVotes
+	// virtual comparisons, reliabilityPriorMean of them truthful.
+	reliabilityPriorMean  = 0.8
+	reliabilityPriorVotes = 5.0
+)
+
+type comparison struct {
+	winner, loser int
+	voter         int
+}
+
+// Crowd holds the pairwise comparisons of the stored ballots of a ratings
+// namespace, together with who made them.
+type Crowd struct {
+	games *Games
+
+	voters     []string
+	voterIndex map[string]int
+
+	comparisons []comparison
+	outcomes    int
+
+	// elo are the current ratings to compare with.
+	elo map[string]float64
+}
+
+func NewCrowd() *Crowd {
+	return &Crowd{
+		games:       NewGames(),
+		voters:      []string{},
+		voterIndex:  map[string]int{},
+		comparisons: []comparison{},
+		elo:         map[string]float64{},
+	}
+}
+
+// LoadCrowd reads the ballots and current ratings of a ratings namespace.
+func LoadCrowd(tx *bolt.Tx, namespace string) (*Crowd, error) {
+	result := NewCrowd()
+
+	err := scorer.ForEachBallot(tx, namespace, func(ballot scorer.Ballot) error {
+		result.AddBallot(ballot)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ballots: %w", err)
+	}
+
+	assets := tx.Bucket(common.NamespaceBucket(common.ScorerAssetsBucket, namespace))
+
+	for _, assetID := range result.games.assets {
+		result.elo[assetID] = scorer.DefaultRating
+
+		if assets == nil {
+			continue
+		}
+
+		record, err := scorer.LoadRecord(assets, assetID)
+		if err != nil {
+			//nolint:wrapcheck
+			return nil, err
+		}
+
+		result.elo[assetID] = record.Rating
+	}
+
+	return result, nil
+}
+
+// AddBallot splits a ballot into a comparison of the winner with every other
+// opponent, or into ties between every pair of opponents. Ballots are only
+// stored for applied outcomes, so a ballot without a winner is a declared
+// tie.
+func (c *Crowd) AddBallot(ballot scorer.Ballot) {
+	c.outcomes++
+
+	voter, ok := c.voterIndex[ballot.VoterID]
+	if !ok {
+		voter = len(c.voters)
+
+		c.voters = append(c.voters, ballot.VoterID)
+		c.voterIndex[ballot.VoterID] = voter
+	}
+
+	if ballot.Winner == "" {
+		for idx, assetID := range ballot.Assets {
+			for _, other := range ballot.Assets[idx+1:] {
+				c.games.games = append(c.games.games, game{a: c.games.node(assetID), b: c.games.node(other), score: 0.5})
+			}
+		}
+
+		return
+	}
+
+	winner := c.games.node(ballot.Winner)
+
+	for _, assetID := range ballot.Assets {
+		if assetID != ballot.Winner {
+			c.comparisons = append(c.comparisons, comparison{winner: winner, loser: c.games.node(assetID), voter: voter})
+		}
+	}
+}
+
+// Fit jointly estimates asset ratings and voter reliability with
+// expectation-maximization. A voter's reliability is the probability that
+// they report the comparison they actually perceive, so votes of unreliable
+// voters count less and votes of voters below 0.5 count as the opposite.
+//
+//nolint:funlen // Expectation and maximization steps belong together
+func (c *Crowd) Fit() CrowdReport {
+	n := len(c.games.assets)
+
+	ratings := make([]float64, n)
+	for i := range ratings {
+		ratings[i] = scorer.DefaultRating
+	}
+
+	reliability := make([]float64, len(c.voters))
+	for i := range reliability {
+		reliability[i] = reliabilityPriorMean
+	}
+
+	truthful := make([]float64, len(c.comparisons))
+	counts := make([]int, len(c.voters))
+
+	for _, comp := range c.comparisons {
+		counts[comp.voter]++
+	}
+
+	games := make([]game, 0, len(c.games.games)+len(c.comparisons))
+	iterations := 0
+
+	for iterations < crowdIterations {
+		iterations++
+
+		// Expectation: how likely each vote was truthful.
+		games = append(games[:0], c.games.games...)
+
+		for idx, comp := range c.comparisons {
+			expected := scorer.CalculateExpectedScore(ratings[comp.winner], ratings[comp.loser])
+			eta := reliability[comp.voter]
+
+			truthful[idx] = eta * expected / (eta*expected + (1-eta)*(1-expected))
+			games = append(games, game{a: comp.winner, b: comp.loser, score: truthful[idx]})
+		}
+
+		// Maximization: ratings from the weighted votes, reliability from how
+		// truthful each voter's votes were.
+		next := c.games.fit(games)
+
+		sums := make([]float64, len(c.voters))
+		for idx, comp := range c.comparisons {
+			sums[comp.voter] += truthful[idx]
+		}
+
+		for voter := range reliability {
+			reliability[voter] = (sums[voter] + reliabilityPriorMean*reliabilityPriorVotes) /
+				(float64(counts[voter]) + reliabilityPriorVotes)
+		}
+
+		delta := 0.0
+		for i := range ratings {
+			delta = max(delta, math.Abs(next[i]-ratings[i]))
+		}
+
+		ratings = next
+
+		if delta < crowdTolerance {
+			break
+		}
+	}
+
+	return c.report(ratings, reliability, counts, iterations)
+}
+
+func (c *Crowd) report(ratings, reliability []float64, counts []int, iterations int) CrowdReport {
+	elo := make([]float64, len(c.games.assets))
+	for i, assetID := range c.games.assets {
+		elo[i] = c.elo[assetID]
+	}
+
+	crowdRanks := ranks(ratings)
+	eloRanks := ranks(elo)
+
+	result := CrowdReport{
+		Outcomes:        c.outcomes,
+		Comparisons:     len(c.comparisons),
+		Iterations:      iterations,
+		RankCorrelation: rankCorrelation(crowdRanks, eloRanks),
+		Ratings:         make([]CrowdRating, 0, len(c.games.assets)),
+		Voters:          make([]VoterReliability, 0, len(c.voters)),
+	}
+
+	for i, assetID := range c.games.assets {
+		result.Ratings = append(result.Ratings, CrowdRating{
+			AssetID:   assetID,
+			Rating:    ratings[i],
+			Rank:      crowdRanks[i],
+			EloRating: elo[i],
+			EloRank:   eloRanks[i],
+		})
+	}
+
+	slices.SortFunc(result.Ratings, func(x, y CrowdRating) int {
+		return cmp.Compare(x.Rank, y.Rank)
+	})
+
+	for i, voterID := range c.voters {
+		result.Voters = append(result.Voters, VoterReliability{
+			VoterID:     voterID,
+			Comparisons: counts[i],
+			Reliability: reliability[i],
+		})
+	}
+
+	slices.SortFunc(result.Voters, func(x, y VoterReliability) int {
+		return cmp.Or(cmp.Compare(x.Reliability, y.Reliability), cmp.Compare(x.VoterID, y.VoterID))
+	})
+
+	return result
+}
+
+// rankCorrelation returns Spearman's rank correlation of two rankings.
+func rankCorrelation(x, y []int) float64 {
+	n := float64(len(x))
+	if n < 2 {
+		return 1
+	}
+
+	sum := 0.0
+
+	for i := range x {
+		d := float64(x[i] - y[i])
+		sum += d * d
+	}
+
+	return 1 - 6*sum/(n*(n*n-1))
+}
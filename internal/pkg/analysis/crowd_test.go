@@ -0,0 +1,51 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	analysis "github.com/vreid/shiki/internal/pkg/analysis"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+)
+
+func TestCrowdFit(t *testing.T) {
+	t.Parallel()
+
+	crowd := analysis.NewCrowd()
+	assets := []string{"a", "b", "c", "d"}
+
+	for round := range 10 {
+		for i, better := range assets {
+			for _, worse := range assets[i+1:] {
+				pair := []string{better, worse}
+				if round%2 == 1 {
+					pair = []string{worse, better}
+				}
+
+				crowd.AddBallot(scorer.Ballot{VoterID: "honest-1", Assets: pair, Winner: better})
+				crowd.AddBallot(scorer.Ballot{VoterID: "honest-2", Assets: pair, Winner: better})
+				crowd.AddBallot(scorer.Ballot{VoterID: "contrarian", Assets: pair, Winner: worse})
+			}
+		}
+	}
+
+	crowd.AddBallot(scorer.Ballot{VoterID: "honest-1", Assets: []string{"a", "d"}})
+
+	report := crowd.Fit()
+
+	assert.Equal(t, 181, report.Outcomes)
+	assert.Equal(t, 180, report.Comparisons)
+	require.Len(t, report.Ratings, 4)
+
+	for idx, assetID := range assets {
+		assert.Equal(t, assetID, report.Ratings[idx].AssetID)
+		assert.Equal(t, idx+1, report.Ratings[idx].Rank)
+	}
+
+	require.Len(t, report.Voters, 3)
+	assert.Equal(t, "contrarian", report.Voters[0].VoterID)
+	assert.Less(t, report.Voters[0].Reliability, 0.5)
+	assert.Greater(t, report.Voters[1].Reliability, 0.8)
+	assert.Equal(t, 60, report.Voters[2].Comparisons)
+}
@@ -0,0 +1,211 @@
+package analysis
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PreferenceGraph is the majority-preference graph of the stored pairwise
+// outcomes: an edge from A to B means A beat B more often than it lost.
+type PreferenceGraph struct {
+	assets []string
+	index  map[string]int
+
+	// out[a][b] holds the margin of the edge from a to b.
+	out []map[int]float64
+	// neighbors[a] holds every asset a has a decided majority against.
+	neighbors []map[int]bool
+}
+
+func NewPreferenceGraph() *PreferenceGraph {
+	return &PreferenceGraph{
+		assets: []string{},
+		index:  map[string]int{},
+	}
+}
+
+// LoadPreferenceGraph builds the preference graph from the stored
+// head-to-head records of a ratings namespace.
+func LoadPreferenceGraph(tx *bolt.Tx, namespace string) (*PreferenceGraph, error) {
+	result := NewPreferenceGraph()
+
+	err := scorer.ForEachPairRecord(tx, namespace, func(assetID string, record scorer.PairRecord) error {
+		if assetID < record.OpponentAssetID {
+			result.AddPair(assetID, record)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load preference graph: %w", err)
+	}
+
+	return result, nil
+}
+
+// AddPair adds the record of assetID against record.OpponentAssetID. Pairs
+// without a majority either way don't add an edge.
+func (g *PreferenceGraph) AddPair(assetID string, record scorer.PairRecord) {
+	games := record.Wins + record.Losses + record.Ties
+	if games == 0 || record.Wins == record.Losses {
+		return
+	}
+
+	a := g.node(assetID)
+	b := g.node(record.OpponentAssetID)
+
+	margin := float64(record.Wins-record.Losses) / float64(games)
+	if margin < 0 {
+		a, b, margin = b, a, -margin
+	}
+
+	g.out[a][b] = margin
+	g.neighbors[a][b] = true
+	g.neighbors[b][a] = true
+}
+
+func (g *PreferenceGraph) node(assetID string) int {
+	idx, ok := g.index[assetID]
+	if ok {
+		return idx
+	}
+
+	idx = len(g.assets)
+
+	g.assets = append(g.assets, assetID)
+	g.index[assetID] = idx
+	g.out = append(g.out, map[int]float64{})
+	g.neighbors = append(g.neighbors, map[int]bool{})
+
+	return idx
+}
+
+// Cycles reports the strongly-connected components of the graph, the top
+// most-violated triads and the share of complete triads that are transitive.
+func (g *PreferenceGraph) Cycles(top int) CycleReport {
+	result := CycleReport{
+		Assets:         len(g.assets),
+		Components:     g.components(),
+		ViolatedTriads: []Triad{},
+		Consistency:    1.0,
+	}
+
+	for a := range g.out {
+		result.Edges += len(g.out[a])
+
+		for b, marginAB := range g.out[a] {
+			for c, marginBC := range g.out[b] {
+				marginCA, ok := g.out[c][a]
+				if !ok || a > b || a > c {
+					continue
+				}
+
+				result.CyclicTriads++
+				result.ViolatedTriads = append(result.ViolatedTriads, Triad{
+					Assets:   [3]string{g.assets[a], g.assets[b], g.assets[c]},
+					Strength: min(marginAB, marginBC, marginCA),
+				})
+			}
+		}
+	}
+
+	for a := range g.neighbors {
+		for b := range g.neighbors[a] {
+			if b < a {
+				continue
+			}
+
+			for c := range g.neighbors[b] {
+				if c > b && g.neighbors[a][c] {
+					result.Triads++
+				}
+			}
+		}
+	}
+
+	if result.Triads > 0 {
+		result.Consistency = 1.0 - float64(result.CyclicTriads)/float64(result.Triads)
+	}
+
+	slices.SortFunc(result.ViolatedTriads, func(x, y Triad) int {
+		return cmp.Or(cmp.Compare(y.Strength, x.Strength), slices.Compare(x.Assets[:], y.Assets[:]))
+	})
+
+	if top >= 0 && len(result.ViolatedTriads) > top {
+		result.ViolatedTriads = result.ViolatedTriads[:top]
+	}
+
+	return result
+}
+
+// components returns the strongly-connected components with more than one
+// asset, largest first, using Tarjan's algorithm.
+func (g *PreferenceGraph) components() [][]string {
+	index := make([]int, len(g.assets))
+	lowlink := make([]int, len(g.assets))
+	onStack := make([]bool, len(g.assets))
+	stack := []int{}
+	counter := 1
+
+	result := [][]string{}
+
+	var strongConnect func(v int)
+
+	strongConnect = func(v int) {
+		index[v] = counter
+		lowlink[v] = counter
+		counter++
+
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for w := range g.out[v] {
+			if index[w] == 0 {
+				strongConnect(w)
+
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+
+		if lowlink[v] != index[v] {
+			return
+		}
+
+		component := []string{}
+
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+
+			component = append(component, g.assets[w])
+
+			if w == v {
+				break
+			}
+		}
+
+		if len(component) > 1 {
+			slices.Sort(component)
+			result = append(result, component)
+		}
+	}
+
+	for v := range g.assets {
+		if index[v] == 0 {
+			strongConnect(v)
+		}
+	}
+
+	slices.SortFunc(result, func(x, y []string) int {
+		return cmp.Or(cmp.Compare(len(y), len(x)), slices.Compare(x, y))
+	})
+
+	return result
+}
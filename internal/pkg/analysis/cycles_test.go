@@ -0,0 +1,54 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	analysis "github.com/vreid/shiki/internal/pkg/analysis"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+)
+
+func TestCycles(t *testing.T) {
+	t.Parallel()
+
+	g := analysis.NewPreferenceGraph()
+
+	// a > b > c > a is a cycle, d loses to everyone.
+	g.AddPair("a", scorer.PairRecord{OpponentAssetID: "b", Wins: 3, Losses: 1})
+	g.AddPair("b", scorer.PairRecord{OpponentAssetID: "c", Wins: 2})
+	g.AddPair("a", scorer.PairRecord{OpponentAssetID: "c", Losses: 4})
+	g.AddPair("d", scorer.PairRecord{OpponentAssetID: "a", Losses: 1})
+	g.AddPair("d", scorer.PairRecord{OpponentAssetID: "b", Losses: 1})
+	g.AddPair("d", scorer.PairRecord{OpponentAssetID: "c", Losses: 1})
+	g.AddPair("a", scorer.PairRecord{OpponentAssetID: "e", Wins: 1, Losses: 1})
+
+	report := g.Cycles(10)
+
+	assert.Equal(t, 4, report.Assets)
+	assert.Equal(t, 6, report.Edges)
+	assert.Equal(t, [][]string{{"a", "b", "c"}}, report.Components)
+	assert.Equal(t, 4, report.Triads)
+	assert.Equal(t, 1, report.CyclicTriads)
+	assert.InDelta(t, 0.75, report.Consistency, 0.0001)
+
+	require.Len(t, report.ViolatedTriads, 1)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, report.ViolatedTriads[0].Assets[:])
+	assert.InDelta(t, 0.5, report.ViolatedTriads[0].Strength, 0.0001)
+}
+
+func TestCyclesTransitive(t *testing.T) {
+	t.Parallel()
+
+	g := analysis.NewPreferenceGraph()
+
+	g.AddPair("a", scorer.PairRecord{OpponentAssetID: "b", Wins: 1})
+	g.AddPair("b", scorer.PairRecord{OpponentAssetID: "c", Wins: 1})
+	g.AddPair("a", scorer.PairRecord{OpponentAssetID: "c", Wins: 1})
+
+	report := g.Cycles(10)
+
+	assert.Empty(t, report.Components)
+	assert.Empty(t, report.ViolatedTriads)
+	assert.InDelta(t, 1.0, report.Consistency, 0.0001)
+}
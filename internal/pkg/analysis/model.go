@@ -0,0 +1,97 @@
+package analysis
+
+type Triad struct {
+	Assets [3]string `json:"assets"`
+
+	// Strength is the smallest majority margin along the cycle. The higher it
+	// is, the more clearly the voters contradict themselves.
+	Strength float64 `json:"strength"`
+}
+
+type CycleReport struct {
+	Assets int `json:"assets"`
+	Edges  int `json:"edges"`
+
+	Triads       int     `json:"triads"`
+	CyclicTriads int     `json:"cyclic_triads"`
+	Consistency  float64 `json:"consistency"`
+
+	Components     [][]string `json:"components"`
+	ViolatedTriads []Triad    `json:"violated_triads"`
+}
+
+type Interval struct {
+	AssetID string `json:"asset_id"`
+
+	Rating     float64 `json:"rating"`
+	RatingLow  float64 `json:"rating_low"`
+	RatingHigh float64 `json:"rating_high"`
+
+	Rank     int `json:"rank"`
+	RankLow  int `json:"rank_low"`
+	RankHigh int `json:"rank_high"`
+}
+
+type BootstrapReport struct {
+	Games      int `json:"games"`
+	Replicates int `json:"replicates"`
+
+	Intervals []Interval `json:"intervals"`
+}
+
+type CrowdRating struct {
+	AssetID string `json:"asset_id"`
+
+	Rating float64 `json:"rating"`
+	Rank   int     `json:"rank"`
+
+	EloRating float64 `json:"elo_rating"`
+	EloRank   int     `json:"elo_rank"`
+}
+
+type VoterReliability struct {
+	VoterID     string `json:"voter_id"`
+	Comparisons int    `json:"comparisons"`
+
+	// Reliability is the estimated probability that the voter reports what
+	// they perceive. Below 0.5 their votes are more likely the opposite.
+	Reliability float64 `json:"reliability"`
+}
+
+type CrowdReport struct {
+	Outcomes    int `json:"outcomes"`
+	Comparisons int `json:"comparisons"`
+	Iterations  int `json:"iterations"`
+
+	// RankCorrelation is Spearman's correlation of the crowd ranking with
+	// the Elo ranking.
+	RankCorrelation float64 `json:"rank_correlation"`
+
+	Ratings []CrowdRating      `json:"ratings"`
+	Voters  []VoterReliability `json:"voters"`
+}
+
+type PositionGroup struct {
+	// Opponents is the number of opponents of the match-ups in the group.
+	Opponents int `json:"opponents"`
+
+	Votes int64 `json:"votes"`
+	Ties  int64 `json:"ties"`
+
+	// Wins and Shares are the decisive votes won by each slot.
+	Wins   []int64   `json:"wins"`
+	Shares []float64 `json:"shares"`
+
+	// Offsets are the rating points each slot is worth, as removed by the
+	// position correction of the scorer.
+	Offsets []float64 `json:"offsets"`
+
+	// ChiSquare tests the wins against an even split over the slots.
+	ChiSquare        float64 `json:"chi_square"`
+	DegreesOfFreedom int     `json:"degrees_of_freedom"`
+}
+
+type PositionReport struct {
+	Ballots int             `json:"ballots"`
+	Groups  []PositionGroup `json:"groups"`
+}
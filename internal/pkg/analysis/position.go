@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	bolt "go.etcd.io/bbolt"
+)
+
+// LoadPositionReport counts the stored ballots of a ratings namespace by the
+// slot the winner was shown in.
+func LoadPositionReport(tx *bolt.Tx, namespace string) (PositionReport, error) {
+	result := PositionReport{Groups: []PositionGroup{}}
+	groups := map[int]*scorer.PositionStats{}
+	ties := map[int]int64{}
+
+	err := scorer.ForEachBallot(tx, namespace, func(ballot scorer.Ballot) error {
+		result.Ballots++
+
+		n := len(ballot.Assets)
+
+		stats, ok := groups[n]
+		if !ok {
+			stats = &scorer.PositionStats{Wins: make([]int64, n)}
+			groups[n] = stats
+		}
+
+		slot := ballot.Slot()
+		if slot < 0 {
+			ties[n]++
+
+			return nil
+		}
+
+		stats.Votes++
+		stats.Wins[slot]++
+
+		return nil
+	})
+	if err != nil {
+		return PositionReport{}, fmt.Errorf("failed to load ballots: %w", err)
+	}
+
+	for n, stats := range groups {
+		result.Groups = append(result.Groups, newPositionGroup(n, *stats, ties[n]))
+	}
+
+	slices.SortFunc(result.Groups, func(x, y PositionGroup) int {
+		return cmp.Compare(x.Opponents, y.Opponents)
+	})
+
+	return result, nil
+}
+
+func newPositionGroup(n int, stats scorer.PositionStats, ties int64) PositionGroup {
+	result := PositionGroup{
+		Opponents:        n,
+		Votes:            stats.Votes,
+		Ties:             ties,
+		Wins:             stats.Wins,
+		Shares:           make([]float64, n),
+		Offsets:          stats.Offsets(),
+		DegreesOfFreedom: n - 1,
+	}
+
+	if stats.Votes == 0 {
+		return result
+	}
+
+	expected := float64(stats.Votes) / float64(n)
+
+	for slot, wins := range stats.Wins {
+		result.Shares[slot] = float64(wins) / float64(stats.Votes)
+
+		d := float64(wins) - expected
+		result.ChiSquare += d * d / expected
+	}
+
+	return result
+}
@@ -0,0 +1,25 @@
+package analysis_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+)
+
+func TestPositionOffsets(t *testing.T) {
+	t.Parallel()
+
+	stats := scorer.PositionStats{Votes: 4, Wins: []int64{3, 1}}
+
+	// With one win added to every slot, the first slot won twice as often.
+	offsets := stats.Offsets()
+	assert.InDelta(t, 400*math.Log10(2), offsets[0]-offsets[1], 0.0001)
+
+	ballot := scorer.Ballot{Assets: []string{"a", "b"}, Winner: "b"}
+	assert.Equal(t, 1, ballot.Slot())
+
+	ballot.Winner = ""
+	assert.Equal(t, -1, ballot.Slot())
+}
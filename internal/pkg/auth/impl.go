@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/oidc"
+	bolt "go.etcd.io/bbolt"
+)
+
+// TokenPrefix starts every API key token, which is <prefix>_<id>_<secret>.
+const TokenPrefix = "shiki"
+
+// AdminTokenKeyID is the ID of the key the --admin-token authenticates as.
+const AdminTokenKeyID = "admin-token"
+
+const (
+	keyIDBytes     = 8
+	keySecretBytes = 32
+
+	contextKey = "api-key"
+)
+
+var (
+	ErrKeyNotFound        = errors.New("API key not found")
+	ErrInvalidKey         = errors.New("invalid API key")
+	ErrInvalidScope       = errors.New("invalid scope")
+	ErrMissingScope       = errors.New("API keys need at least one scope")
+	ErrKeysBucketNotFound = errors.New("API keys bucket doesn't exist")
+)
+
+type AuthService struct {
+	DatabaseService *common.DatabaseService
+
+	AdminToken string
+
+	AnonymousVoting bool
+	PublicRatings   bool
+}
+
+func NewAuthService(i do.Injector) (*AuthService, error) {
+	databaseService := do.MustInvoke[*common.DatabaseService](i)
+
+	adminToken := do.MustInvokeNamed[string](i, "admin-token")
+	anonymousVoting := do.MustInvokeNamed[bool](i, "anonymous-voting")
+	publicRatings := do.MustInvokeNamed[bool](i, "public-ratings")
+
+	result := &AuthService{
+		DatabaseService: databaseService,
+
+		AdminToken: adminToken,
+
+		AnonymousVoting: anonymousVoting,
+		PublicRatings:   publicRatings,
+	}
+
+	echoService, err := do.Invoke[*common.EchoService](i)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create echo service: %w", err)
+	}
+
+	echoService.Register(func(e *echo.Echo) {
+		e.Use(result.Authenticate())
+	})
+
+	return result, nil
+}
+
+// ParseScopes parses scope names and removes duplicates.
+func ParseScopes(values []string) ([]Scope, error) {
+	result := []Scope{}
+
+	for _, value := range values {
+		scope := Scope(strings.TrimSpace(value))
+		if !slices.Contains(Scopes, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, value)
+		}
+
+		if !slices.Contains(result, scope) {
+			result = append(result, scope)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, ErrMissingScope
+	}
+
+	return result, nil
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Create stores a new key and returns it with its token. Only the hash of the
+// token's secret is stored, so the token can't be shown again.
+func Create(tx *bolt.Tx, name string, scopes []Scope) (Key, string, error) {
+	keys := tx.Bucket([]byte(common.APIKeysBucket))
+	if keys == nil {
+		return Key{}, "", ErrKeysBucketNotFound
+	}
+
+	if len(scopes) == 0 {
+		return Key{}, "", ErrMissingScope
+	}
+
+	keyID, err := randomHex(keyIDBytes)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	secret, err := randomHex(keySecretBytes)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	key := Key{
+		ID:        keyID,
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err = put(keys, record{Key: key, Hash: hashSecret(secret)})
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	return key, strings.Join([]string{TokenPrefix, keyID, secret}, "_"), nil
+}
+
+func put(keys *bolt.Bucket, r record) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key: %w", err)
+	}
+
+	err = keys.Put([]byte(r.ID), value)
+	if err != nil {
+		return fmt.Errorf("failed to put API key: %w", err)
+	}
+
+	return nil
+}
+
+func load(tx *bolt.Tx, keyID string) (record, error) {
+	keys := tx.Bucket([]byte(common.APIKeysBucket))
+	if keys == nil {
+		return record{}, ErrKeysBucketNotFound
+	}
+
+	value := keys.Get([]byte(keyID))
+	if value == nil {
+		return record{}, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+
+	var result record
+
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return record{}, fmt.Errorf("failed to unmarshal API key %s: %w", keyID, err)
+	}
+
+	return result, nil
+}
+
+// List reads every stored key, including revoked ones, ordered by ID.
+func List(tx *bolt.Tx) ([]Key, error) {
+	keys := tx.Bucket([]byte(common.APIKeysBucket))
+	if keys == nil {
+		return nil, ErrKeysBucketNotFound
+	}
+
+	result := []Key{}
+
+	err := keys.ForEach(func(k, _ []byte) error {
+		r, err := load(tx, string(k))
+		if err != nil {
+			return err
+		}
+
+		result = append(result, r.Key)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return result, nil
+}
+
+// Revoke marks a key as revoked. Revoked keys are kept so they can still be
+// listed.
+func Revoke(tx *bolt.Tx, keyID string) (Key, error) {
+	r, err := load(tx, keyID)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if !r.Revoked() {
+		r.RevokedAt = time.Now().UTC()
+
+		err = put(tx.Bucket([]byte(common.APIKeysBucket)), r)
+		if err != nil {
+			return Key{}, err
+		}
+	}
+
+	return r.Key, nil
+}
+
+// Verify returns the key of a token if the token is valid and not revoked.
+func Verify(tx *bolt.Tx, token string) (Key, error) {
+	parts := strings.Split(token, "_")
+	if len(parts) != 3 || parts[0] != TokenPrefix {
+		return Key{}, ErrInvalidKey
+	}
+
+	r, err := load(tx, parts[1])
+	if errors.Is(err, ErrKeyNotFound) {
+		return Key{}, ErrInvalidKey
+	}
+
+	if err != nil {
+		return Key{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(parts[2])), []byte(r.Hash)) != 1 || r.Revoked() {
+		return Key{}, ErrInvalidKey
+	}
+
+	return r.Key, nil
+}
+
+// KeyFromContext returns the key a request was authenticated with.
+func KeyFromContext(c echo.Context) (Key, bool) {
+	key, ok := c.Get(contextKey).(Key)
+
+	return key, ok
+}
+
+func (s *AuthService) verify(token string) (Key, error) {
+	if len(s.AdminToken) > 0 && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1 {
+		return Key{ID: AdminTokenKeyID, Name: AdminTokenKeyID, Scopes: []Scope{ScopeAdmin}}, nil
+	}
+
+	var result Key
+
+	err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		result, err = Verify(tx, token)
+
+		return err
+	})
+
+	//nolint:wrapcheck
+	return result, err
+}
+
+// Authenticate reads the bearer token of a request and stores its key in the
+// context. Requests without a token pass through anonymously, requests with
+// an invalid token are rejected.
+func (s *AuthService) Authenticate() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return next(c)
+			}
+
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization header")
+			}
+
+			key, err := s.verify(strings.TrimSpace(token))
+			if errors.Is(err, ErrInvalidKey) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+			}
+
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to verify API key")
+			}
+
+			c.Set(contextKey, key)
+
+			return next(c)
+		}
+	}
+}
+
+// Require only lets requests through that were authenticated with a key
+// granting one of the scopes. Without scopes any key is enough.
+func (s *AuthService) Require(scopes ...Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key, ok := KeyFromContext(c)
+			if !ok {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing API key")
+			}
+
+			if len(scopes) == 0 || slices.ContainsFunc(scopes, key.Allows) {
+				return next(c)
+			}
+
+			return echo.NewHTTPError(http.StatusForbidden, "API key lacks the required scope")
+		}
+	}
+}
+
+// RequireVoter requires any key or a logged in user for the voting endpoints
+// unless voting is anonymous.
+func (s *AuthService) RequireVoter() echo.MiddlewareFunc {
+	if s.AnonymousVoting {
+		return passThrough
+	}
+
+	requireKey := s.Require()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withKey := requireKey(next)
+
+		return func(c echo.Context) error {
+			if _, ok := oidc.UserFromContext(c); ok {
+				return next(c)
+			}
+
+			return withKey(c)
+		}
+	}
+}
+
+// RequireReader requires a read-only key for the ratings and contests
+// endpoints unless ratings are public.
+func (s *AuthService) RequireReader() echo.MiddlewareFunc {
+	if s.PublicRatings {
+		return passThrough
+	}
+
+	return s.Require(ScopeReadOnly)
+}
+
+func passThrough(next echo.HandlerFunc) echo.HandlerFunc {
+	return next
+}
@@ -0,0 +1,133 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auth "github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestService(t *testing.T) *auth.AuthService {
+	t.Helper()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	return &auth.AuthService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+		AdminToken: "admin-secret",
+	}
+}
+
+func createKey(t *testing.T, service *auth.AuthService, scopes ...auth.Scope) (auth.Key, string) {
+	t.Helper()
+
+	var (
+		key   auth.Key
+		token string
+	)
+
+	require.NoError(t, service.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		var err error
+
+		key, token, err = auth.Create(tx, "test", scopes)
+
+		return err
+	}))
+
+	return key, token
+}
+
+func TestParseScopes(t *testing.T) {
+	t.Parallel()
+
+	scopes, err := auth.ParseScopes([]string{"upload", "read-only", "upload"})
+	require.NoError(t, err)
+	assert.Equal(t, []auth.Scope{auth.ScopeUpload, auth.ScopeReadOnly}, scopes)
+
+	_, err = auth.ParseScopes([]string{"write"})
+	require.ErrorIs(t, err, auth.ErrInvalidScope)
+
+	_, err = auth.ParseScopes(nil)
+	require.ErrorIs(t, err, auth.ErrMissingScope)
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+	key, token := createKey(t, service, auth.ScopeUpload)
+
+	err := service.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		verified, err := auth.Verify(tx, token)
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, verified.ID)
+
+		_, err = auth.Verify(tx, token+"0")
+		require.ErrorIs(t, err, auth.ErrInvalidKey)
+
+		_, err = auth.Verify(tx, "shiki_unknown_secret")
+		require.ErrorIs(t, err, auth.ErrInvalidKey)
+
+		_, err = auth.Revoke(tx, key.ID)
+		require.NoError(t, err)
+
+		_, err = auth.Verify(tx, token)
+		require.ErrorIs(t, err, auth.ErrInvalidKey)
+
+		keys, err := auth.List(tx)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.True(t, keys[0].Revoked())
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRequire(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+	_, uploadToken := createKey(t, service, auth.ScopeUpload)
+
+	e := echo.New()
+	e.Use(service.Authenticate())
+	e.GET("/upload", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, service.Require(auth.ScopeUpload))
+	e.GET("/admin", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, service.Require(auth.ScopeAdmin))
+
+	for _, tc := range []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{"/upload", "", http.StatusUnauthorized},
+		{"/upload", "shiki_0_0", http.StatusUnauthorized},
+		{"/upload", uploadToken, http.StatusOK},
+		{"/admin", uploadToken, http.StatusForbidden},
+		{"/admin", "admin-secret", http.StatusOK},
+		{"/upload", "admin-secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+tc.token)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.status, rec.Code, tc.path)
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import "time"
+
+type Scope string
+
+const (
+	// ScopeUpload allows uploading assets.
+	ScopeUpload Scope = "upload"
+
+	// ScopeAdmin allows everything, including the /admin API.
+	ScopeAdmin Scope = "admin"
+
+	// ScopeReadOnly allows reading ratings and contests when they aren't
+	// public.
+	ScopeReadOnly Scope = "read-only"
+)
+
+var Scopes = []Scope{ScopeUpload, ScopeAdmin, ScopeReadOnly}
+
+type Key struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	Scopes []Scope `json:"scopes"`
+
+	CreatedAt time.Time `json:"created_at"`
+	RevokedAt time.Time `json:"revoked_at,omitzero"`
+}
+
+// Revoked reports whether the key was revoked.
+func (k Key) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// Allows reports whether the key grants a scope. The admin scope grants every
+// scope.
+func (k Key) Allows(scope Scope) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// record is a key as stored, with the hash of its secret.
+type record struct {
+	Key
+
+	Hash string `json:"hash"`
+}
@@ -0,0 +1,299 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+var (
+	ErrUnknownCompression = errors.New("unknown compression")
+	ErrDatabaseInUse      = errors.New("database is in use, stop the server before restoring")
+	ErrCorruptBackup      = errors.New("backup failed the integrity check")
+	ErrMissingBucket      = errors.New("backup is missing a bucket")
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Layout of the bbolt meta pages, see go.etcd.io/bbolt/internal/common.
+const (
+	pageHeaderSize = 16
+	metaEnd        = pageHeaderSize + 64
+	metaMagic      = 0xED0CDAED
+)
+
+// NewWriter returns a writer that compresses what is written to it into w.
+// It has to be closed to flush the compressed stream, which doesn't close w.
+// zstd would need a dependency the module doesn't have, so it isn't offered.
+func NewWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionNone, "":
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCompression, compression)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// NewReader returns a reader of a plain or gzip-compressed backup.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	bufferedReader := bufio.NewReader(r)
+
+	magic, err := bufferedReader.Peek(len(gzipMagic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	if !bytes.Equal(magic, gzipMagic) {
+		return io.NopCloser(bufferedReader), nil
+	}
+
+	gzipReader, err := gzip.NewReader(bufferedReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress backup: %w", err)
+	}
+
+	return gzipReader, nil
+}
+
+// Write streams a consistent copy of the database, taken within a single read
+// transaction, to w.
+func Write(db *bolt.DB, w io.Writer, compression string) error {
+	compressed, err := NewWriter(w, compression)
+	if err != nil {
+		return err
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(compressed)
+
+		//nolint:wrapcheck
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	err = compressed.Close()
+	if err != nil {
+		return fmt.Errorf("failed to compress backup: %w", err)
+	}
+
+	return nil
+}
+
+// Verify opens a database file read-only, runs the bbolt consistency check and
+// makes sure it is a shiki database this version can migrate.
+func Verify(dbPath string) error {
+	err := checkSize(dbPath)
+	if err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorruptBackup, err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			return fmt.Errorf("%w: %w", ErrCorruptBackup, err)
+		}
+
+		version := common.SchemaVersion(tx)
+		if version > common.LatestSchemaVersion() {
+			return fmt.Errorf("%w: %d", common.ErrSchemaTooNew, version)
+		}
+
+		// Databases from before schema versioning have no meta bucket.
+		if version == 0 && tx.Bucket([]byte("scorer:ratings")) == nil {
+			return fmt.Errorf("%w: %s", ErrMissingBucket, "scorer:ratings")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify backup: %w", err)
+	}
+
+	return nil
+}
+
+// checkSize makes sure the file covers every page its meta pages refer to.
+// bbolt maps the file into memory and would fault on a truncated file instead
+// of returning an error. Only the meta pages are read.
+func checkSize(dbPath string) error {
+	f, err := os.Open(dbPath) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	size := info.Size()
+	meta := make([]byte, metaEnd)
+
+	if size < metaEnd {
+		return fmt.Errorf("%w: file is truncated or not a database", ErrCorruptBackup)
+	}
+
+	_, err = f.ReadAt(meta, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	// The second meta page follows the first one, one page size later.
+	secondMeta := int64(binary.LittleEndian.Uint32(meta[pageHeaderSize+8:]))
+
+	valid := false
+	required := uint64(0)
+
+	for _, offset := range []int64{0, secondMeta} {
+		if offset+metaEnd > size {
+			continue
+		}
+
+		_, err = f.ReadAt(meta, offset)
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		if binary.LittleEndian.Uint32(meta[pageHeaderSize:]) != metaMagic {
+			continue
+		}
+
+		valid = true
+		pageSize := uint64(binary.LittleEndian.Uint32(meta[pageHeaderSize+8:]))
+		pgid := binary.LittleEndian.Uint64(meta[pageHeaderSize+40:])
+		required = max(required, pgid*pageSize)
+	}
+
+	if !valid || uint64(size) < required {
+		return fmt.Errorf("%w: file is truncated or not a database", ErrCorruptBackup)
+	}
+
+	return nil
+}
+
+// Restore reads a plain or gzip-compressed backup, verifies it and swaps it in
+// as the database of dataDir. The previous database is kept next to it with a
+// timestamp suffix. It refuses to run while the database is in use.
+//
+//nolint:cyclop,funlen // Restoring safely requires these steps
+func Restore(dataDir string, r io.Reader) (string, error) {
+	dbPath := path.Join(dataDir, common.DatabaseFile)
+
+	_, err := os.Stat(dbPath)
+	if err == nil {
+		db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+		if errors.Is(err, bolt.ErrTimeout) {
+			return "", ErrDatabaseInUse
+		}
+
+		if err == nil {
+			_ = db.Close()
+		}
+	}
+
+	err = os.MkdirAll(dataDir, 0750)
+	if err != nil {
+		return "", fmt.Errorf("failed to create database path: %w", err)
+	}
+
+	src, err := NewReader(r)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = src.Close()
+	}()
+
+	tmpPath := dbPath + ".restore"
+
+	//nolint:gosec
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create restore file: %w", err)
+	}
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmpPath)
+
+		return "", fmt.Errorf("failed to write restore file: %w", err)
+	}
+
+	err = dst.Sync()
+	if err == nil {
+		err = dst.Close()
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+
+		return "", fmt.Errorf("failed to write restore file: %w", err)
+	}
+
+	err = Verify(tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+
+		return "", err
+	}
+
+	previousPath := ""
+
+	_, err = os.Stat(dbPath)
+	if err == nil {
+		previousPath = fmt.Sprintf("%s.%s", dbPath, time.Now().UTC().Format("20060102T150405Z"))
+
+		err = os.Rename(dbPath, previousPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to move previous database aside: %w", err)
+		}
+	}
+
+	err = os.Rename(tmpPath, dbPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to move restored database into place: %w", err)
+	}
+
+	return previousPath, nil
+}
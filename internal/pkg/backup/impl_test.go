@@ -0,0 +1,72 @@
+package backup_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	backup "github.com/vreid/shiki/internal/pkg/backup"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+func writeBackup(t *testing.T, compression string) []byte {
+	t.Helper()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(common.ScorerAssetsBucket)).Put([]byte("a-1"), common.EncodeAssetRecord(common.AssetRecord{Rating: 1510.0}))
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, backup.Write(db, &buf, compression))
+
+	return buf.Bytes()
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	for _, compression := range []string{backup.CompressionNone, backup.CompressionGzip} {
+		dataDir := t.TempDir()
+
+		previousPath, err := backup.Restore(dataDir, bytes.NewReader(writeBackup(t, compression)))
+		require.NoError(t, err)
+		assert.Empty(t, previousPath)
+
+		previousPath, err = backup.Restore(dataDir, bytes.NewReader(writeBackup(t, compression)))
+		require.NoError(t, err)
+		assert.FileExists(t, previousPath)
+
+		db, err := bolt.Open(filepath.Join(dataDir, common.DatabaseFile), 0600, &bolt.Options{ReadOnly: true})
+		require.NoError(t, err)
+
+		err = db.View(func(tx *bolt.Tx) error {
+			record, err := common.DecodeAssetRecord(tx.Bucket([]byte(common.ScorerAssetsBucket)).Get([]byte("a-1")))
+			require.NoError(t, err)
+			assert.InDelta(t, 1510.0, record.Rating, 0.0001)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+	}
+}
+
+func TestRestoreCorrupt(t *testing.T) {
+	t.Parallel()
+
+	data := writeBackup(t, backup.CompressionNone)
+
+	_, err := backup.Restore(t.TempDir(), bytes.NewReader(data[:len(data)/2]))
+	require.ErrorIs(t, err, backup.ErrCorruptBackup)
+
+	_, err = backup.Restore(t.TempDir(), bytes.NewReader([]byte("not a database")))
+	require.ErrorIs(t, err, backup.ErrCorruptBackup)
+}
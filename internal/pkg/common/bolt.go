@@ -2,7 +2,9 @@ package common
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"log"
 	"math"
 	"os"
 	"path"
@@ -13,8 +15,51 @@ import (
 )
 
 const (
-	ScorerRatingsBucket = "scorer:ratings"
-	ScorerCountBucket   = "scorer:count"
+	DatabaseFile = "shiki.db"
+	SnapshotFile = "shiki.snapshot.db"
+)
+
+const (
+	ScorerAssetsBucket   = "scorer:assets"
+	ScorerHistoryBucket  = "scorer:history"
+	ScorerPairsBucket    = "scorer:pairs"
+	ScorerOutcomesBucket = "scorer:outcomes"
+	ScorerVotersBucket   = "scorer:voters"
+	ScorerBallotsBucket  = "scorer:ballots"
+
+	// ScorerPositionsBucket counts the decisive votes per ratings namespace
+	// by the slot of the winner.
+	ScorerPositionsBucket = "scorer:positions"
+
+	// ScorerQuarantineBucket holds the outcomes of unreliable voters that
+	// weren't applied.
+	ScorerQuarantineBucket = "scorer:quarantine"
+
+	GoldBucket = "gold"
+
+	OutcomeQueueBucket = "queue:outcomes"
+
+	ContestsBucket = "contests"
+
+	APIKeysBucket = "auth:keys"
+)
+
+// NamespaceBucket returns the name of a scorer bucket within a ratings
+// namespace. The default namespace "" uses the plain bucket name.
+func NamespaceBucket(bucket, namespace string) []byte {
+	if namespace == "" {
+		return []byte(bucket)
+	}
+
+	return []byte(bucket + "@" + namespace)
+}
+
+var (
+	ErrSchemaOutdated = errors.New("database schema is outdated, run shiki db migrate")
+
+	// ErrDatabaseInUse is returned when another process, usually the
+	// server, holds the database open for writing.
+	ErrDatabaseInUse = errors.New("database is in use, stop the server first")
 )
 
 type DatabaseService struct {
@@ -24,33 +69,95 @@ type DatabaseService struct {
 func NewDatabaseService(i do.Injector) (*DatabaseService, error) {
 	dataDir := do.MustInvokeNamed[string](i, "data-dir")
 
+	db, err := OpenDatabase(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := Migrate(db, false)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	for _, migration := range migrations {
+		log.Printf("applied migration %d: %s", migration.Version, migration.Name)
+	}
+
+	return &DatabaseService{
+		DB: db,
+	}, nil
+}
+
+// OpenDatabase opens the database of dataDir for writing, as is, without
+// applying migrations.
+func OpenDatabase(dataDir string) (*bolt.DB, error) {
 	err := os.MkdirAll(dataDir, 0750)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database path: %w", err)
 	}
 
-	dbPath := path.Join(dataDir, "shiki.db")
+	dbPath := path.Join(dataDir, DatabaseFile)
 
 	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %w", ErrDatabaseInUse, err)
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		for _, bucket := range []string{
-			ScorerRatingsBucket,
-			ScorerCountBucket,
-		} {
-			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
-			if err != nil {
-				return fmt.Errorf("failed to create %s bucket: %w", bucket, err)
-			}
+	return db, nil
+}
+
+// NewReadOnlyDatabaseService opens an existing database without write
+// access. Any number of read-only services can share the database. While the
+// server holds the database open for writing, the latest snapshot written by
+// the SnapshotService is opened instead.
+func NewReadOnlyDatabaseService(i do.Injector) (*DatabaseService, error) {
+	dataDir := do.MustInvokeNamed[string](i, "data-dir")
+
+	options := &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true}
+
+	db, err := bolt.Open(path.Join(dataDir, DatabaseFile), 0600, options)
+	if errors.Is(err, bolt.ErrTimeout) {
+		snapshotPath := path.Join(dataDir, SnapshotFile)
+
+		info, statErr := os.Stat(snapshotPath)
+		if statErr != nil {
+			return nil, fmt.Errorf("database is in use and no snapshot is available: %w", err)
 		}
 
+		log.Printf("database is in use, reading snapshot from %s", info.ModTime().Format(time.RFC3339))
+
+		db, err = bolt.Open(snapshotPath, 0600, options)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	var version int64
+
+	_ = db.View(func(tx *bolt.Tx) error {
+		version = SchemaVersion(tx)
+
 		return nil
 	})
+
+	switch {
+	case version < LatestSchemaVersion():
+		err = ErrSchemaOutdated
+	case version > LatestSchemaVersion():
+		err = ErrSchemaTooNew
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database buckets: %w", err)
+		_ = db.Close()
+
+		return nil, fmt.Errorf("%w: version %d, expected %d", err, version, LatestSchemaVersion())
 	}
 
 	return &DatabaseService{
@@ -0,0 +1,25 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	common "github.com/vreid/shiki/internal/pkg/common"
+)
+
+func TestOpenDatabaseInUse(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+
+	db, err := common.OpenDatabase(dataDir)
+	require.NoError(t, err)
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// Writes have to go through the server while it's running.
+	_, err = common.OpenDatabase(dataDir)
+	require.ErrorIs(t, err, common.ErrDatabaseInUse)
+}
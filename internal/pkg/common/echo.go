@@ -2,7 +2,11 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -14,13 +18,52 @@ type EchoService struct {
 	port int
 }
 
+// IPExtractor returns how the client IP is taken from a request. Without
+// trusted proxies it is the address of the connection, so clients can't pick
+// their IP with X-Forwarded-For. Otherwise X-Forwarded-For is read through
+// the given proxy ranges.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, proxy := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %s: %w", proxy, err)
+			}
+
+			ipRange = &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}
+		}
+
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
 func NewEchoService(i do.Injector) (*EchoService, error) {
 	port := do.MustInvokeNamed[int](i, "port")
+	trustedProxies := do.MustInvokeNamed[[]string](i, "trusted-proxies")
+
+	ipExtractor, err := IPExtractor(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
 	e := echo.New()
 
 	e.HideBanner = true
 	e.HidePort = false
+	e.IPExtractor = ipExtractor
 
 	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
 		Format: "${id} ${remote_ip} ${status} ${method} ${path} ${error} ${latency_human} ${bytes_in} ${bytes_out}\n",
@@ -39,7 +82,7 @@ func (s *EchoService) Register(c func(e *echo.Echo)) {
 
 func (s *EchoService) Start() error {
 	err := s.echo.Start(fmt.Sprintf(":%d", s.port))
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start echo server: %w", err)
 	}
 
@@ -54,3 +97,19 @@ func (s *EchoService) Shutdown(ctx context.Context) error {
 
 	return nil
 }
+
+// QueryInt reads a non-negative integer query parameter, or _default if it
+// is missing. Invalid values are rejected with 400.
+func QueryInt(c echo.Context, name string, _default int) (int, error) {
+	value := c.QueryParam(name)
+	if len(value) == 0 {
+		return _default, nil
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil || result < 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid "+name+" value")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,348 @@
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	MetaBucket = "meta"
+
+	SchemaVersionKey = "schema-version"
+)
+
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of shiki supports")
+
+// Migration upgrades the database from Version-1 to Version. Migrations must
+// not depend on code that may change later, so they spell out bucket names
+// and encodings themselves.
+type Migration struct {
+	Version int64
+	Name    string
+
+	Up func(tx *bolt.Tx) error
+}
+
+// Migrations lists every migration, ordered by version.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create scorer buckets",
+		Up: func(tx *bolt.Tx) error {
+			for _, bucket := range []string{
+				"scorer:ratings",
+				"scorer:count",
+				"scorer:wins",
+				"scorer:losses",
+				"scorer:history",
+				"scorer:pairs",
+				"scorer:outcomes",
+			} {
+				_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+				if err != nil {
+					return fmt.Errorf("failed to create %s bucket: %w", bucket, err)
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version: 2,
+		Name:    "merge rating, count, wins and losses into asset records",
+		Up:      mergeAssetRecords,
+	},
+	{
+		Version: 3,
+		Name:    "create contests bucket",
+		Up: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte("contests"))
+			if err != nil {
+				return fmt.Errorf("failed to create contests bucket: %w", err)
+			}
+
+			return nil
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create API keys bucket",
+		Up: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte("auth:keys"))
+			if err != nil {
+				return fmt.Errorf("failed to create API keys bucket: %w", err)
+			}
+
+			return nil
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create voters bucket",
+		Up: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte("scorer:voters"))
+			if err != nil {
+				return fmt.Errorf("failed to create voters bucket: %w", err)
+			}
+
+			return nil
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create gold and quarantine buckets",
+		Up: func(tx *bolt.Tx) error {
+			for _, bucket := range []string{"gold", "scorer:quarantine"} {
+				_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+				if err != nil {
+					return fmt.Errorf("failed to create %s bucket: %w", bucket, err)
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create ballots bucket",
+		Up: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte("scorer:ballots"))
+			if err != nil {
+				return fmt.Errorf("failed to create ballots bucket: %w", err)
+			}
+
+			return nil
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create positions bucket",
+		Up: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte("scorer:positions"))
+			if err != nil {
+				return fmt.Errorf("failed to create positions bucket: %w", err)
+			}
+
+			return nil
+		},
+	},
+}
+
+// LatestSchemaVersion is the schema version after applying every migration.
+func LatestSchemaVersion() int64 {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// SchemaVersion returns the schema version of the database. Databases created
+// before versioning was introduced have version 0.
+func SchemaVersion(tx *bolt.Tx) int64 {
+	meta := tx.Bucket([]byte(MetaBucket))
+	if meta == nil {
+		return 0
+	}
+
+	return BytesToInt64(meta.Get([]byte(SchemaVersionKey)), 0)
+}
+
+// PendingMigrations returns the migrations that have not been applied yet.
+func PendingMigrations(tx *bolt.Tx) ([]Migration, error) {
+	version := SchemaVersion(tx)
+	if version > LatestSchemaVersion() {
+		return nil, fmt.Errorf("%w: %d > %d", ErrSchemaTooNew, version, LatestSchemaVersion())
+	}
+
+	result := []Migration{}
+
+	for _, migration := range Migrations {
+		if migration.Version > version {
+			result = append(result, migration)
+		}
+	}
+
+	return result, nil
+}
+
+// Migrate applies every pending migration, each in its own transaction
+// together with the bump of the schema version. With dryRun it only reports
+// what it would apply.
+func Migrate(db *bolt.DB, dryRun bool) ([]Migration, error) {
+	var pending []Migration
+
+	err := db.View(func(tx *bolt.Tx) error {
+		var err error
+
+		pending, err = PendingMigrations(tx)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if dryRun {
+		return pending, nil
+	}
+
+	for _, migration := range pending {
+		err := db.Update(func(tx *bolt.Tx) error {
+			err := migration.Up(tx)
+			if err != nil {
+				return err
+			}
+
+			meta, err := tx.CreateBucketIfNotExists([]byte(MetaBucket))
+			if err != nil {
+				return fmt.Errorf("failed to create %s bucket: %w", MetaBucket, err)
+			}
+
+			//nolint:wrapcheck
+			return meta.Put([]byte(SchemaVersionKey), Int64ToBytes(migration.Version))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// mergeAssetRecords replaces the parallel scorer:ratings, scorer:count,
+// scorer:wins and scorer:losses buckets with one record per asset in
+// scorer:assets. Ties are summed up from the head-to-head records, first-seen
+// and last-updated are taken from the rating history.
+//
+//nolint:cyclop,funlen // One pass over each legacy bucket
+func mergeAssetRecords(tx *bolt.Tx) error {
+	records := map[string]*AssetRecord{}
+
+	record := func(assetID []byte) *AssetRecord {
+		result, ok := records[string(assetID)]
+		if !ok {
+			result = &AssetRecord{Rating: 1500.0, Deviation: 350.0}
+			records[string(assetID)] = result
+		}
+
+		return result
+	}
+
+	legacyInt64 := func(k, v []byte) (int64, error) {
+		if len(v) != 8 {
+			return 0, fmt.Errorf("%w: %q has %d bytes", ErrInvalidAssetRecord, k, len(v))
+		}
+
+		//nolint:gosec // Intentional conversion from binary encoding
+		return int64(binary.LittleEndian.Uint64(v)), nil
+	}
+
+	if ratings := tx.Bucket([]byte("scorer:ratings")); ratings != nil {
+		err := ratings.ForEach(func(k, v []byte) error {
+			bits, err := legacyInt64(k, v)
+			if err != nil {
+				return err
+			}
+
+			//nolint:gosec // Intentional conversion from binary encoding
+			record(k).Rating = math.Float64frombits(uint64(bits))
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read ratings: %w", err)
+		}
+	}
+
+	for bucket, field := range map[string]func(*AssetRecord) *int64{
+		"scorer:count":  func(r *AssetRecord) *int64 { return &r.Games },
+		"scorer:wins":   func(r *AssetRecord) *int64 { return &r.Wins },
+		"scorer:losses": func(r *AssetRecord) *int64 { return &r.Losses },
+	} {
+		legacy := tx.Bucket([]byte(bucket))
+		if legacy == nil {
+			continue
+		}
+
+		err := legacy.ForEach(func(k, v []byte) error {
+			value, err := legacyInt64(k, v)
+			if err != nil {
+				return err
+			}
+
+			*field(record(k)) = value
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", bucket, err)
+		}
+	}
+
+	if pairs := tx.Bucket([]byte("scorer:pairs")); pairs != nil {
+		err := pairs.ForEachBucket(func(assetID []byte) error {
+			return pairs.Bucket(assetID).ForEach(func(_, v []byte) error {
+				if len(v) != 24 {
+					return fmt.Errorf("%w: pair record of %q has %d bytes", ErrInvalidAssetRecord, assetID, len(v))
+				}
+
+				//nolint:gosec // Intentional conversion from binary encoding
+				record(assetID).Ties += int64(binary.LittleEndian.Uint64(v[16:24]))
+
+				return nil
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read pairs: %w", err)
+		}
+	}
+
+	if history := tx.Bucket([]byte("scorer:history")); history != nil {
+		err := history.ForEachBucket(func(assetID []byte) error {
+			cursor := history.Bucket(assetID).Cursor()
+
+			_, first := cursor.First()
+			_, last := cursor.Last()
+
+			if len(first) < 8 || len(last) < 8 {
+				return nil
+			}
+
+			//nolint:gosec // Intentional conversion from binary encoding
+			record(assetID).FirstSeen = int64(binary.LittleEndian.Uint64(first[0:8]))
+			//nolint:gosec // Intentional conversion from binary encoding
+			record(assetID).LastUpdated = int64(binary.LittleEndian.Uint64(last[0:8]))
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+	}
+
+	assets, err := tx.CreateBucketIfNotExists([]byte("scorer:assets"))
+	if err != nil {
+		return fmt.Errorf("failed to create scorer:assets bucket: %w", err)
+	}
+
+	for assetID, record := range records {
+		err := assets.Put([]byte(assetID), EncodeAssetRecord(*record))
+		if err != nil {
+			return fmt.Errorf("failed to put record of %s: %w", assetID, err)
+		}
+	}
+
+	for _, bucket := range []string{"scorer:ratings", "scorer:count", "scorer:wins", "scorer:losses"} {
+		if tx.Bucket([]byte(bucket)) == nil {
+			continue
+		}
+
+		err := tx.DeleteBucket([]byte(bucket))
+		if err != nil {
+			return fmt.Errorf("failed to delete %s bucket: %w", bucket, err)
+		}
+	}
+
+	return nil
+}
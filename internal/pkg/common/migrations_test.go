@@ -0,0 +1,141 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	common "github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+func schemaVersion(t *testing.T, db *bolt.DB) int64 {
+	t.Helper()
+
+	var version int64
+
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		version = common.SchemaVersion(tx)
+
+		return nil
+	}))
+
+	return version
+}
+
+func TestMigrationsOrdered(t *testing.T) {
+	t.Parallel()
+
+	for idx, migration := range common.Migrations {
+		assert.Equal(t, int64(idx+1), migration.Version, migration.Name)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenDatabase(t)
+
+	migrations, err := common.Migrate(db, true)
+	require.NoError(t, err)
+	assert.Len(t, migrations, len(common.Migrations))
+	assert.Equal(t, int64(0), schemaVersion(t, db))
+
+	migrations, err = common.Migrate(db, false)
+	require.NoError(t, err)
+	assert.Len(t, migrations, len(common.Migrations))
+	assert.Equal(t, common.LatestSchemaVersion(), schemaVersion(t, db))
+
+	migrations, err = common.Migrate(db, false)
+	require.NoError(t, err)
+	assert.Empty(t, migrations)
+
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		assert.NotNil(t, tx.Bucket([]byte(common.ScorerHistoryBucket)))
+
+		return nil
+	}))
+}
+
+func TestMigrateSchemaTooNew(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenDatabase(t)
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucket([]byte(common.MetaBucket))
+		if err != nil {
+			return err
+		}
+
+		return meta.Put([]byte(common.SchemaVersionKey), common.Int64ToBytes(common.LatestSchemaVersion()+1))
+	}))
+
+	_, err := common.Migrate(db, false)
+	require.ErrorIs(t, err, common.ErrSchemaTooNew)
+}
+
+func TestMigrateAssetRecords(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenDatabase(t)
+
+	history := make([]byte, 24)
+	copy(history, common.Int64ToBytes(1760000000))
+
+	pair := make([]byte, 24)
+	copy(pair[16:], common.Int64ToBytes(2))
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		require.NoError(t, common.Migrations[0].Up(tx))
+
+		meta, err := tx.CreateBucket([]byte(common.MetaBucket))
+		require.NoError(t, err)
+		require.NoError(t, meta.Put([]byte(common.SchemaVersionKey), common.Int64ToBytes(1)))
+
+		require.NoError(t, tx.Bucket([]byte("scorer:ratings")).Put([]byte("a-1"), common.Float64ToBytes(1510.0)))
+		require.NoError(t, tx.Bucket([]byte("scorer:count")).Put([]byte("a-1"), common.Int64ToBytes(4)))
+		require.NoError(t, tx.Bucket([]byte("scorer:wins")).Put([]byte("a-1"), common.Int64ToBytes(3)))
+		require.NoError(t, tx.Bucket([]byte("scorer:losses")).Put([]byte("a-1"), common.Int64ToBytes(1)))
+
+		assetHistory, err := tx.Bucket([]byte(common.ScorerHistoryBucket)).CreateBucket([]byte("a-1"))
+		require.NoError(t, err)
+		require.NoError(t, assetHistory.Put([]byte{0, 0, 0, 0, 0, 0, 0, 1}, history))
+
+		assetPairs, err := tx.Bucket([]byte(common.ScorerPairsBucket)).CreateBucket([]byte("a-2"))
+		require.NoError(t, err)
+
+		return assetPairs.Put([]byte("a-3"), pair)
+	}))
+
+	migrations, err := common.Migrate(db, false)
+	require.NoError(t, err)
+	require.Len(t, migrations, len(common.Migrations)-1)
+
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket([]byte("scorer:ratings")))
+		assert.Nil(t, tx.Bucket([]byte("scorer:count")))
+
+		assets := tx.Bucket([]byte(common.ScorerAssetsBucket))
+
+		record, err := common.DecodeAssetRecord(assets.Get([]byte("a-1")))
+		require.NoError(t, err)
+		assert.Equal(t, common.AssetRecord{
+			Rating:      1510.0,
+			Deviation:   350.0,
+			Games:       4,
+			Wins:        3,
+			Losses:      1,
+			FirstSeen:   1760000000,
+			LastUpdated: 1760000000,
+		}, record)
+
+		record, err = common.DecodeAssetRecord(assets.Get([]byte("a-2")))
+		require.NoError(t, err)
+		assert.InDelta(t, 1500.0, record.Rating, 0.0001)
+		assert.Equal(t, int64(2), record.Ties)
+
+		return nil
+	}))
+}
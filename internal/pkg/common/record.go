@@ -0,0 +1,105 @@
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// AssetRecordVersion is the encoding version written by EncodeAssetRecord.
+const AssetRecordVersion = 1
+
+var ErrInvalidAssetRecord = errors.New("invalid asset record")
+
+// AssetRecord holds everything the scorer tracks about an asset. Timestamps
+// are Unix seconds.
+type AssetRecord struct {
+	Rating float64
+	// Deviation is how uncertain Rating is. It starts at 350 and shrinks
+	// with every decisive game, as in Glicko.
+	Deviation float64
+
+	Games  int64
+	Wins   int64
+	Losses int64
+	Ties   int64
+
+	FirstSeen   int64
+	LastUpdated int64
+}
+
+// EncodeAssetRecord encodes a record as a version byte, the two float64
+// fields in little-endian order and the counters and timestamps as varints.
+func EncodeAssetRecord(record AssetRecord) []byte {
+	buf := make([]byte, 1+2*8+6*binary.MaxVarintLen64)
+	buf[0] = AssetRecordVersion
+
+	binary.LittleEndian.PutUint64(buf[1:9], math.Float64bits(record.Rating))
+	binary.LittleEndian.PutUint64(buf[9:17], math.Float64bits(record.Deviation))
+
+	n := 17
+
+	for _, value := range []int64{
+		record.Games,
+		record.Wins,
+		record.Losses,
+		record.Ties,
+		record.FirstSeen,
+		record.LastUpdated,
+	} {
+		n += binary.PutVarint(buf[n:], value)
+	}
+
+	return buf[:n]
+}
+
+// DecodeAssetRating decodes only the rating of an encoded record, for passes
+// over many records that need nothing else.
+func DecodeAssetRating(b []byte) (float64, error) {
+	if len(b) < 17 || b[0] != AssetRecordVersion {
+		return 0, fmt.Errorf("%w: not a version %d record", ErrInvalidAssetRecord, AssetRecordVersion)
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b[1:9])), nil
+}
+
+func DecodeAssetRecord(b []byte) (AssetRecord, error) {
+	if len(b) < 17 {
+		return AssetRecord{}, fmt.Errorf("%w: %d bytes is too short", ErrInvalidAssetRecord, len(b))
+	}
+
+	if b[0] != AssetRecordVersion {
+		return AssetRecord{}, fmt.Errorf("%w: unknown version %d", ErrInvalidAssetRecord, b[0])
+	}
+
+	record := AssetRecord{
+		Rating:    math.Float64frombits(binary.LittleEndian.Uint64(b[1:9])),
+		Deviation: math.Float64frombits(binary.LittleEndian.Uint64(b[9:17])),
+	}
+
+	rest := b[17:]
+
+	for _, field := range []*int64{
+		&record.Games,
+		&record.Wins,
+		&record.Losses,
+		&record.Ties,
+		&record.FirstSeen,
+		&record.LastUpdated,
+	} {
+		value, n := binary.Varint(rest)
+		if n <= 0 {
+			return AssetRecord{}, fmt.Errorf("%w: truncated", ErrInvalidAssetRecord)
+		}
+
+		*field = value
+		rest = rest[n:]
+	}
+
+	if len(rest) != 0 {
+		return AssetRecord{}, fmt.Errorf("%w: %d trailing bytes", ErrInvalidAssetRecord, len(rest))
+	}
+
+	return record, nil
+}
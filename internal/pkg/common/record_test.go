@@ -0,0 +1,48 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	common "github.com/vreid/shiki/internal/pkg/common"
+)
+
+func TestAssetRecordRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	record := common.AssetRecord{
+		Rating:      1523.25,
+		Deviation:   350.0,
+		Games:       42,
+		Wins:        30,
+		Losses:      12,
+		Ties:        3,
+		FirstSeen:   1760000000,
+		LastUpdated: 1760003600,
+	}
+
+	encoded := common.EncodeAssetRecord(record)
+	assert.Len(t, encoded, 31)
+
+	decoded, err := common.DecodeAssetRecord(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, record, decoded)
+
+	rating, err := common.DecodeAssetRating(encoded)
+	require.NoError(t, err)
+	assert.InDelta(t, record.Rating, rating, 0)
+
+	_, err = common.DecodeAssetRecord(encoded[:20])
+	require.ErrorIs(t, err, common.ErrInvalidAssetRecord)
+
+	_, err = common.DecodeAssetRecord(append(encoded, 0))
+	require.ErrorIs(t, err, common.ErrInvalidAssetRecord)
+
+	encoded[0] = 0
+	_, err = common.DecodeAssetRecord(encoded)
+	require.ErrorIs(t, err, common.ErrInvalidAssetRecord)
+
+	_, err = common.DecodeAssetRating(encoded)
+	require.ErrorIs(t, err, common.ErrInvalidAssetRecord)
+}
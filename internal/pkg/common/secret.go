@@ -0,0 +1,15 @@
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// DeriveKey derives a key for one purpose from a secret, so the same secret
+// isn't used for several purposes directly.
+func DeriveKey(secret, purpose string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(purpose))
+
+	return h.Sum(nil)
+}
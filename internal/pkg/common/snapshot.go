@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/samber/do/v2"
+	bolt "go.etcd.io/bbolt"
+)
+
+// SnapshotService periodically writes a consistent copy of the database, so
+// read-only tools can inspect it while the server holds the lock on the live
+// database.
+type SnapshotService struct {
+	DatabaseService *DatabaseService
+
+	Path     string
+	Interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewSnapshotService(i do.Injector) (*SnapshotService, error) {
+	databaseService := do.MustInvoke[*DatabaseService](i)
+	dataDir := do.MustInvokeNamed[string](i, "data-dir")
+	snapshotIntervalSeconds := do.MustInvokeNamed[int](i, "snapshot-interval-seconds")
+
+	return &SnapshotService{
+		DatabaseService: databaseService,
+
+		Path:     path.Join(dataDir, SnapshotFile),
+		Interval: time.Duration(snapshotIntervalSeconds) * time.Second,
+	}, nil
+}
+
+// Start writes a snapshot every interval. A non-positive interval disables
+// snapshots.
+func (s *SnapshotService) Start() {
+	if s.Interval <= 0 {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := s.WriteSnapshot()
+				if err != nil {
+					log.Printf("failed to write snapshot: %v", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stops the periodic snapshots and writes a final one.
+func (s *SnapshotService) Shutdown(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+
+	close(s.stop)
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop snapshots: %w", ctx.Err())
+	}
+
+	return s.WriteSnapshot()
+}
+
+// WriteSnapshot copies the database within a read transaction to a temporary
+// file and atomically moves it into place.
+func (s *SnapshotService) WriteSnapshot() error {
+	tmpPath := s.Path + ".tmp"
+
+	err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(tmpPath, 0600)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	err = os.Rename(tmpPath, s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to move snapshot into place: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package common_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/samber/do/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	common "github.com/vreid/shiki/internal/pkg/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestReadOnlyDatabaseFallsBackToSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+
+	i := do.New()
+
+	do.ProvideNamedValue(i, "data-dir", dataDir)
+	do.ProvideNamedValue(i, "snapshot-interval-seconds", 0)
+	do.Provide(i, common.NewDatabaseService)
+	do.Provide(i, common.NewSnapshotService)
+
+	snapshotService := do.MustInvoke[*common.SnapshotService](i)
+
+	defer func() {
+		_ = snapshotService.DatabaseService.Shutdown()
+	}()
+
+	err := snapshotService.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(common.ScorerAssetsBucket)).Put([]byte("a-1"), common.EncodeAssetRecord(common.AssetRecord{Rating: 1510.0}))
+	})
+	require.NoError(t, err)
+
+	readOnly := do.New()
+
+	do.ProvideNamedValue(readOnly, "data-dir", dataDir)
+	do.Provide(readOnly, common.NewReadOnlyDatabaseService)
+
+	_, err = do.Invoke[*common.DatabaseService](readOnly)
+	require.ErrorIs(t, err, bolt.ErrTimeout)
+
+	require.NoError(t, snapshotService.WriteSnapshot())
+	assert.FileExists(t, filepath.Join(dataDir, common.SnapshotFile))
+
+	readOnly = do.New()
+
+	do.ProvideNamedValue(readOnly, "data-dir", dataDir)
+	do.Provide(readOnly, common.NewReadOnlyDatabaseService)
+
+	dbService, err := do.Invoke[*common.DatabaseService](readOnly)
+	require.NoError(t, err)
+
+	defer func() {
+		_ = dbService.Shutdown()
+	}()
+
+	err = dbService.DB.View(func(tx *bolt.Tx) error {
+		record, err := common.DecodeAssetRecord(tx.Bucket([]byte(common.ScorerAssetsBucket)).Get([]byte("a-1")))
+		require.NoError(t, err)
+		assert.InDelta(t, 1510.0, record.Rating, 0.0001)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
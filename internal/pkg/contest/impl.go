@@ -0,0 +1,311 @@
+package contest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/metadata"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultContestID names the contest configured by the server flags. It is
+// served by the routes without a contest ID.
+const DefaultContestID = "default"
+
+var (
+	ErrContestNotFound        = errors.New("contest not found")
+	ErrContestExists          = errors.New("contest already exists")
+	ErrInvalidContestID       = errors.New("contest ID must be 1-64 lowercase letters, digits or dashes")
+	ErrInvalidOpponents       = errors.New("contests need at least two opponents per match-up")
+	ErrNotEnoughAssets        = errors.New("contest has fewer assets than opponents per match-up")
+	ErrContestsBucketNotFound = errors.New("contests bucket doesn't exist")
+
+	ErrCriterionNotFound    = errors.New("criterion not found")
+	ErrInvalidCriterion     = errors.New("criteria must be given as <id>=<prompt>")
+	ErrInvalidCriterionID   = errors.New("criterion ID must be 1-64 lowercase letters, digits or dashes")
+	ErrDuplicateCriterionID = errors.New("duplicate criterion ID")
+)
+
+// idPattern matches contest and criterion IDs. They must not contain the
+// separators of ratings namespaces.
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+type ContestService struct {
+	DatabaseService *common.DatabaseService
+
+	Default Contest
+}
+
+func NewContestService(i do.Injector) (*ContestService, error) {
+	databaseService := do.MustInvoke[*common.DatabaseService](i)
+	authService := do.MustInvoke[*auth.AuthService](i)
+
+	opponents := do.MustInvokeNamed[int](i, "opponents")
+
+	criteria, err := ParseCriteria(do.MustInvokeNamed[[]string](i, "criteria"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse criteria: %w", err)
+	}
+
+	// Match-ups of the default contest ask for no proof of work, as they
+	// did before there were contests.
+	result := &ContestService{
+		DatabaseService: databaseService,
+
+		Default: Contest{
+			ID:        DefaultContestID,
+			Name:      DefaultContestID,
+			Assets:    metadata.Assets,
+			Opponents: opponents,
+			Criteria:  criteria,
+		},
+	}
+
+	echoService, err := do.Invoke[*common.EchoService](i)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create echo service: %w", err)
+	}
+
+	echoService.Register(func(e *echo.Echo) {
+		apiGroup := e.Group("/api")
+
+		requireReader := authService.RequireReader()
+
+		apiGroup.GET("/contests", result.GetContests, requireReader)
+		apiGroup.GET("/contests/:contestID", result.GetContest, requireReader)
+	})
+
+	return result, nil
+}
+
+// ParseCriteria parses criteria given as <id>=<prompt> and checks that their
+// IDs are valid and unique.
+func ParseCriteria(values []string) ([]Criterion, error) {
+	result := []Criterion{}
+
+	for _, value := range values {
+		criterionID, prompt, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(prompt) == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCriterion, value)
+		}
+
+		result = append(result, Criterion{
+			ID:     strings.TrimSpace(criterionID),
+			Prompt: strings.TrimSpace(prompt),
+		})
+	}
+
+	err := validateCriteria(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func validateCriteria(criteria []Criterion) error {
+	seen := make(map[string]bool, len(criteria))
+
+	for _, criterion := range criteria {
+		if !idPattern.MatchString(criterion.ID) {
+			return fmt.Errorf("%w: %q", ErrInvalidCriterionID, criterion.ID)
+		}
+
+		if seen[criterion.ID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateCriterionID, criterion.ID)
+		}
+
+		seen[criterion.ID] = true
+	}
+
+	return nil
+}
+
+// Validate checks that a contest can be stored.
+func Validate(contest Contest) error {
+	if !idPattern.MatchString(contest.ID) || contest.ID == DefaultContestID {
+		return fmt.Errorf("%w: %q", ErrInvalidContestID, contest.ID)
+	}
+
+	if contest.Opponents < 2 {
+		return ErrInvalidOpponents
+	}
+
+	if len(contest.Assets) < contest.Opponents {
+		return fmt.Errorf("%w: %d < %d", ErrNotEnoughAssets, len(contest.Assets), contest.Opponents)
+	}
+
+	return validateCriteria(contest.Criteria)
+}
+
+// Create stores a new contest.
+func Create(tx *bolt.Tx, contest Contest) error {
+	err := Validate(contest)
+	if err != nil {
+		return err
+	}
+
+	contests := tx.Bucket([]byte(common.ContestsBucket))
+	if contests == nil {
+		return ErrContestsBucketNotFound
+	}
+
+	if contests.Get([]byte(contest.ID)) != nil {
+		return fmt.Errorf("%w: %s", ErrContestExists, contest.ID)
+	}
+
+	value, err := json.Marshal(contest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal contest: %w", err)
+	}
+
+	err = contests.Put([]byte(contest.ID), value)
+	if err != nil {
+		return fmt.Errorf("failed to put contest: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads a stored contest.
+func Load(tx *bolt.Tx, contestID string) (Contest, error) {
+	contests := tx.Bucket([]byte(common.ContestsBucket))
+	if contests == nil {
+		return Contest{}, ErrContestsBucketNotFound
+	}
+
+	value := contests.Get([]byte(contestID))
+	if value == nil {
+		return Contest{}, fmt.Errorf("%w: %s", ErrContestNotFound, contestID)
+	}
+
+	var result Contest
+
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return Contest{}, fmt.Errorf("failed to unmarshal contest %s: %w", contestID, err)
+	}
+
+	return result, nil
+}
+
+// List reads every stored contest, ordered by ID.
+func List(tx *bolt.Tx) ([]Contest, error) {
+	contests := tx.Bucket([]byte(common.ContestsBucket))
+	if contests == nil {
+		return nil, ErrContestsBucketNotFound
+	}
+
+	result := []Contest{}
+
+	err := contests.ForEach(func(k, _ []byte) error {
+		contest, err := Load(tx, string(k))
+		if err != nil {
+			return err
+		}
+
+		result = append(result, contest)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contests: %w", err)
+	}
+
+	return result, nil
+}
+
+// Get returns a contest by ID. The empty ID refers to the default contest.
+func (s *ContestService) Get(contestID string) (Contest, error) {
+	if contestID == "" || contestID == DefaultContestID {
+		return s.Default, nil
+	}
+
+	var result Contest
+
+	err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		result, err = Load(tx, contestID)
+
+		return err
+	})
+	if err != nil {
+		return Contest{}, fmt.Errorf("failed to load contest: %w", err)
+	}
+
+	return result, nil
+}
+
+// FromContext returns the contest named by the contestID route parameter, or
+// the default contest on routes without one.
+func (s *ContestService) FromContext(c echo.Context) (Contest, error) {
+	result, err := s.Get(c.Param("contestID"))
+	if errors.Is(err, ErrContestNotFound) {
+		return Contest{}, echo.NewHTTPError(http.StatusNotFound, "contest not found")
+	}
+
+	if err != nil {
+		return Contest{}, echo.NewHTTPError(http.StatusInternalServerError, "failed to load contest")
+	}
+
+	return result, nil
+}
+
+// CriterionFromContext returns the criterion named by the criterion query
+// parameter. The zero criterion is returned when none is given.
+func CriterionFromContext(c echo.Context, contest Contest) (Criterion, error) {
+	criterionID := c.QueryParam("criterion")
+	if criterionID == "" {
+		return Criterion{}, nil
+	}
+
+	result, ok := contest.Criterion(criterionID)
+	if !ok {
+		return Criterion{}, echo.NewHTTPError(http.StatusNotFound, "criterion not found")
+	}
+
+	return result, nil
+}
+
+func (s *ContestService) GetContests(c echo.Context) error {
+	var contests []Contest
+
+	err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		contests, err = List(tx)
+
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list contests")
+	}
+
+	result := []Summary{s.Default.Summary()}
+
+	for _, contest := range contests {
+		result = append(result, contest.Summary())
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, result, "  ")
+}
+
+func (s *ContestService) GetContest(c echo.Context) error {
+	contest, err := s.FromContext(c)
+	if err != nil {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, contest, "  ")
+}
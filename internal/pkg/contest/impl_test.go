@@ -0,0 +1,116 @@
+package contest_test
+
+import (
+	"testing"
+
+	"github.com/samber/do/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	contest "github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := contest.Contest{ID: "photos-2025", Assets: []string{"a-1", "a-2", "a-3"}, Opponents: 3}
+	require.NoError(t, contest.Validate(valid))
+
+	for _, id := range []string{"", "default", "Photos", "-photos", "photos@1"} {
+		invalid := valid
+		invalid.ID = id
+
+		require.ErrorIs(t, contest.Validate(invalid), contest.ErrInvalidContestID, id)
+	}
+
+	invalid := valid
+	invalid.Opponents = 4
+
+	require.ErrorIs(t, contest.Validate(invalid), contest.ErrNotEnoughAssets)
+
+	invalid.Opponents = 1
+
+	require.ErrorIs(t, contest.Validate(invalid), contest.ErrInvalidOpponents)
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	photos := contest.Contest{ID: "photos", Assets: []string{"a-1", "a-2"}, Opponents: 2}
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		return contest.Create(tx, photos)
+	}))
+
+	require.ErrorIs(t, db.Update(func(tx *bolt.Tx) error {
+		return contest.Create(tx, photos)
+	}), contest.ErrContestExists)
+
+	service := &contest.ContestService{
+		DatabaseService: &common.DatabaseService{DB: db},
+		Default:         contest.Contest{ID: contest.DefaultContestID},
+	}
+
+	loaded, err := service.Get("photos")
+	require.NoError(t, err)
+	assert.Equal(t, photos, loaded)
+	assert.Equal(t, "photos", loaded.Namespace())
+
+	loaded, err = service.Get("")
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Namespace())
+
+	_, err = service.Get("unknown")
+	require.ErrorIs(t, err, contest.ErrContestNotFound)
+}
+
+func TestParseCriteria(t *testing.T) {
+	t.Parallel()
+
+	criteria, err := contest.ParseCriteria([]string{"sharpness=Which is sharper?", " aesthetics = Which is prettier? "})
+	require.NoError(t, err)
+	assert.Equal(t, []contest.Criterion{
+		{ID: "sharpness", Prompt: "Which is sharper?"},
+		{ID: "aesthetics", Prompt: "Which is prettier?"},
+	}, criteria)
+
+	_, err = contest.ParseCriteria([]string{"sharpness"})
+	require.ErrorIs(t, err, contest.ErrInvalidCriterion)
+
+	_, err = contest.ParseCriteria([]string{"Sharp#1=Which is sharper?"})
+	require.ErrorIs(t, err, contest.ErrInvalidCriterionID)
+
+	_, err = contest.ParseCriteria([]string{"sharpness=Which is sharper?", "sharpness=Which is crisper?"})
+	require.ErrorIs(t, err, contest.ErrDuplicateCriterionID)
+}
+
+func TestDefaultContestDifficulty(t *testing.T) {
+	t.Parallel()
+
+	for _, difficulty := range []int{0, 2} {
+		i := do.New()
+
+		do.ProvideValue(i, &common.DatabaseService{})
+		do.ProvideNamedValue(i, "port", 0)
+		do.ProvideNamedValue(i, "trusted-proxies", []string{})
+		do.ProvideNamedValue(i, "admin-token", "")
+		do.ProvideNamedValue(i, "anonymous-voting", true)
+		do.ProvideNamedValue(i, "public-ratings", true)
+		do.ProvideNamedValue(i, "base-difficulty", difficulty)
+		do.ProvideNamedValue(i, "opponents", 3)
+		do.ProvideNamedValue(i, "criteria", []string{})
+		do.Provide(i, common.NewEchoService)
+		do.Provide(i, auth.NewAuthService)
+		do.Provide(i, contest.NewContestService)
+
+		// The default contest asks for no proof of work, whatever
+		// --base-difficulty says.
+		contestService := do.MustInvoke[*contest.ContestService](i)
+		assert.Zero(t, contestService.Default.Difficulty)
+	}
+}
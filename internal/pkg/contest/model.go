@@ -0,0 +1,86 @@
+package contest
+
+import "time"
+
+// Criterion is a question the voters of a contest answer, such as "Which
+// is sharper?". Each criterion keeps its own ratings.
+type Criterion struct {
+	ID     string `json:"id"`
+	Prompt string `json:"prompt"`
+}
+
+type Contest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	Assets []string `json:"assets"`
+
+	Opponents  int `json:"opponents"`
+	Difficulty int `json:"difficulty"`
+
+	Criteria []Criterion `json:"criteria,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+// Namespace returns the ratings namespace of the contest. The default contest
+// keeps its ratings in the plain scorer buckets.
+func (c Contest) Namespace() string {
+	if c.ID == DefaultContestID {
+		return ""
+	}
+
+	return c.ID
+}
+
+// Criterion returns the criterion with the given ID.
+func (c Contest) Criterion(criterionID string) (Criterion, bool) {
+	for _, criterion := range c.Criteria {
+		if criterion.ID == criterionID {
+			return criterion, true
+		}
+	}
+
+	return Criterion{}, false
+}
+
+// CriterionNamespace returns the ratings namespace of a criterion within the
+// ratings namespace of a contest. Without a criterion it is the contest's.
+func CriterionNamespace(namespace, criterionID string) string {
+	if criterionID == "" {
+		return namespace
+	}
+
+	return namespace + "#" + criterionID
+}
+
+// Summary is a contest without its asset list.
+type Summary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	Assets int `json:"assets"`
+
+	Opponents  int `json:"opponents"`
+	Difficulty int `json:"difficulty"`
+
+	Criteria []Criterion `json:"criteria,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+func (c Contest) Summary() Summary {
+	return Summary{
+		ID:   c.ID,
+		Name: c.Name,
+
+		Assets: len(c.Assets),
+
+		Opponents:  c.Opponents,
+		Difficulty: c.Difficulty,
+
+		Criteria: c.Criteria,
+
+		CreatedAt: c.CreatedAt,
+	}
+}
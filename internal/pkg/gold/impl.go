@@ -0,0 +1,195 @@
+package gold
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	bolt "go.etcd.io/bbolt"
+)
+
+const idLength = 12
+
+var (
+	ErrPairNotFound       = errors.New("gold pair not found")
+	ErrPairExists         = errors.New("gold pair already exists")
+	ErrInvalidPair        = errors.New("gold pairs need at least two distinct assets")
+	ErrInvalidWinner      = errors.New("winner must be one of the assets")
+	ErrWrongOpponents     = errors.New("gold pairs need as many assets as the contest has opponents")
+	ErrUnknownAsset       = errors.New("asset isn't part of the contest")
+	ErrMissingCriterion   = errors.New("contest has criteria, gold pairs need a criterion")
+	ErrGoldBucketNotFound = errors.New("gold bucket doesn't exist")
+)
+
+// Namespace returns the ratings namespace the pair is served in.
+func (p Pair) Namespace() string {
+	return contest.CriterionNamespace(p.ContestID, p.CriterionID)
+}
+
+// Key returns the key gold pairs are stored under: the namespace and the
+// sorted asset IDs, so an outcome can be matched regardless of the order its
+// opponents were shown in.
+func Key(namespace string, assetIDs []string) string {
+	sorted := slices.Clone(assetIDs)
+	slices.Sort(sorted)
+
+	return namespace + "|" + strings.Join(sorted, ",")
+}
+
+// NewPair returns a validated pair with its ID.
+func NewPair(contestID, criterionID string, assetIDs []string, winner string) (Pair, error) {
+	sorted := slices.Clone(assetIDs)
+	slices.Sort(sorted)
+
+	if len(slices.Compact(sorted)) != len(assetIDs) || len(assetIDs) < 2 {
+		return Pair{}, ErrInvalidPair
+	}
+
+	if !slices.Contains(assetIDs, winner) {
+		return Pair{}, fmt.Errorf("%w: %s", ErrInvalidWinner, winner)
+	}
+
+	result := Pair{
+		ContestID:   contestID,
+		CriterionID: criterionID,
+		Assets:      assetIDs,
+		Winner:      winner,
+	}
+
+	hash := sha256.Sum256([]byte(Key(result.Namespace(), assetIDs)))
+	result.ID = hex.EncodeToString(hash[:])[:idLength]
+
+	return result, nil
+}
+
+// NewContestPair returns a validated pair of a contest. Only pairs the
+// matchmaker can serve are accepted: one asset per opponent, all from the
+// contest, and a criterion if the contest has any.
+func NewContestPair(current contest.Contest, criterionID string, assetIDs []string, winner string) (Pair, error) {
+	if criterionID == "" && len(current.Criteria) > 0 {
+		return Pair{}, ErrMissingCriterion
+	}
+
+	if _, ok := current.Criterion(criterionID); criterionID != "" && !ok {
+		return Pair{}, fmt.Errorf("%w: %s", contest.ErrCriterionNotFound, criterionID)
+	}
+
+	if len(assetIDs) != current.Opponents {
+		return Pair{}, fmt.Errorf("%w: %d", ErrWrongOpponents, current.Opponents)
+	}
+
+	for _, assetID := range assetIDs {
+		if !slices.Contains(current.Assets, assetID) {
+			return Pair{}, fmt.Errorf("%w: %s", ErrUnknownAsset, assetID)
+		}
+	}
+
+	return NewPair(current.Namespace(), criterionID, assetIDs, winner)
+}
+
+// Add stores a gold pair.
+func Add(tx *bolt.Tx, pair Pair) error {
+	pairs := tx.Bucket([]byte(common.GoldBucket))
+	if pairs == nil {
+		return ErrGoldBucketNotFound
+	}
+
+	key := []byte(Key(pair.Namespace(), pair.Assets))
+	if pairs.Get(key) != nil {
+		return fmt.Errorf("%w: %s", ErrPairExists, pair.ID)
+	}
+
+	value, err := json.Marshal(pair)
+	if err != nil {
+		return fmt.Errorf("failed to marshal gold pair: %w", err)
+	}
+
+	err = pairs.Put(key, value)
+	if err != nil {
+		return fmt.Errorf("failed to put gold pair: %w", err)
+	}
+
+	return nil
+}
+
+// Lookup returns the gold pair of a namespace with exactly the given assets.
+func Lookup(tx *bolt.Tx, namespace string, assetIDs []string) (Pair, bool, error) {
+	pairs := tx.Bucket([]byte(common.GoldBucket))
+	if pairs == nil {
+		return Pair{}, false, nil
+	}
+
+	value := pairs.Get([]byte(Key(namespace, assetIDs)))
+	if value == nil {
+		return Pair{}, false, nil
+	}
+
+	var result Pair
+
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return Pair{}, false, fmt.Errorf("failed to unmarshal gold pair: %w", err)
+	}
+
+	return result, true, nil
+}
+
+// List reads the gold pairs of a namespace, or of every namespace if
+// namespace is nil.
+func List(tx *bolt.Tx, namespace *string) ([]Pair, error) {
+	pairs := tx.Bucket([]byte(common.GoldBucket))
+	if pairs == nil {
+		return nil, ErrGoldBucketNotFound
+	}
+
+	result := []Pair{}
+
+	err := pairs.ForEach(func(_, v []byte) error {
+		var pair Pair
+
+		err := json.Unmarshal(v, &pair)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal gold pair: %w", err)
+		}
+
+		if namespace == nil || pair.Namespace() == *namespace {
+			result = append(result, pair)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gold pairs: %w", err)
+	}
+
+	return result, nil
+}
+
+// Remove deletes a gold pair by ID.
+func Remove(tx *bolt.Tx, pairID string) (Pair, error) {
+	all, err := List(tx, nil)
+	if err != nil {
+		return Pair{}, err
+	}
+
+	for _, pair := range all {
+		if pair.ID != pairID {
+			continue
+		}
+
+		err = tx.Bucket([]byte(common.GoldBucket)).Delete([]byte(Key(pair.Namespace(), pair.Assets)))
+		if err != nil {
+			return Pair{}, fmt.Errorf("failed to delete gold pair: %w", err)
+		}
+
+		return pair, nil
+	}
+
+	return Pair{}, fmt.Errorf("%w: %s", ErrPairNotFound, pairID)
+}
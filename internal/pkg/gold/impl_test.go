@@ -0,0 +1,89 @@
+package gold_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	gold "github.com/vreid/shiki/internal/pkg/gold"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestNewPair(t *testing.T) {
+	t.Parallel()
+
+	pair, err := gold.NewPair("photos", "sharp", []string{"a", "b"}, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "photos#sharp", pair.Namespace())
+
+	swapped, err := gold.NewPair("photos", "sharp", []string{"b", "a"}, "b")
+	require.NoError(t, err)
+	assert.Equal(t, pair.ID, swapped.ID)
+
+	_, err = gold.NewPair("", "", []string{"a", "a"}, "a")
+	require.ErrorIs(t, err, gold.ErrInvalidPair)
+
+	_, err = gold.NewPair("", "", []string{"a", "b"}, "c")
+	require.ErrorIs(t, err, gold.ErrInvalidWinner)
+}
+
+func TestNewContestPair(t *testing.T) {
+	t.Parallel()
+
+	photos := contest.Contest{
+		ID:        "photos",
+		Assets:    []string{"a", "b", "c"},
+		Opponents: 2,
+		Criteria:  []contest.Criterion{{ID: "sharp", Prompt: "Which is sharper?"}},
+	}
+
+	pair, err := gold.NewContestPair(photos, "sharp", []string{"a", "b"}, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "photos#sharp", pair.Namespace())
+
+	_, err = gold.NewContestPair(photos, "", []string{"a", "b"}, "b")
+	require.ErrorIs(t, err, gold.ErrMissingCriterion)
+
+	_, err = gold.NewContestPair(photos, "color", []string{"a", "b"}, "b")
+	require.ErrorIs(t, err, contest.ErrCriterionNotFound)
+
+	_, err = gold.NewContestPair(photos, "sharp", []string{"a", "b", "c"}, "b")
+	require.ErrorIs(t, err, gold.ErrWrongOpponents)
+
+	_, err = gold.NewContestPair(photos, "sharp", []string{"a", "d"}, "a")
+	require.ErrorIs(t, err, gold.ErrUnknownAsset)
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	pair, err := gold.NewPair("", "", []string{"a", "b", "c"}, "c")
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		require.NoError(t, gold.Add(tx, pair))
+		require.ErrorIs(t, gold.Add(tx, pair), gold.ErrPairExists)
+
+		found, ok, err := gold.Lookup(tx, "", []string{"c", "a", "b"})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, pair.ID, found.ID)
+
+		_, ok, err = gold.Lookup(tx, "photos", []string{"a", "b", "c"})
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = gold.Remove(tx, pair.ID)
+		require.NoError(t, err)
+
+		_, err = gold.Remove(tx, pair.ID)
+		require.ErrorIs(t, err, gold.ErrPairNotFound)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,17 @@
+package gold
+
+import "time"
+
+// Pair is a curated match-up with a known winner. Its opponents are served
+// like any other match-up and voters are graded on it.
+type Pair struct {
+	ID string `json:"id"`
+
+	ContestID   string `json:"contest_id,omitempty"`
+	CriterionID string `json:"criterion_id,omitempty"`
+
+	Assets []string `json:"assets"`
+	Winner string   `json:"winner"`
+
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
@@ -0,0 +1,653 @@
+package integrity
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	bolt "go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+)
+
+// DefaultTolerance is how far the mean rating may drift from the default
+// rating before it is reported. Elo updates are zero-sum, so any drift beyond
+// rounding means ratings were changed outside of the scorer.
+const DefaultTolerance = 1.0
+
+type Options struct {
+	// KnownAssets are the asset IDs that may appear in each ratings
+	// namespace. Unknown asset IDs are not reported for namespaces missing
+	// from it.
+	KnownAssets map[string][]string
+
+	// Repair fixes what can be fixed. It requires a writable transaction.
+	Repair bool
+
+	Tolerance float64
+}
+
+type pairTotals struct {
+	wins   int64
+	losses int64
+	ties   int64
+}
+
+// namespacedBuckets are the scorer buckets that exist once per ratings
+// namespace.
+var namespacedBuckets = []string{
+	common.ScorerAssetsBucket,
+	common.ScorerHistoryBucket,
+	common.ScorerPairsBucket,
+	common.ScorerBallotsBucket,
+}
+
+type checker struct {
+	tx      *bolt.Tx
+	options Options
+	repair  bool
+
+	report Report
+
+	// State of the namespace being checked.
+	namespace string
+	known     map[string]bool
+	records   map[string]common.AssetRecord
+	totals    map[string]*pairTotals
+}
+
+// Check validates the key and value shapes of every bucket, finds records
+// that disagree with the head-to-head records or refer to unknown assets and
+// checks that ratings still add up. With Options.Repair, malformed entries
+// are removed and asset records are rebuilt from the head-to-head records and
+// rating history.
+func Check(tx *bolt.Tx, options Options) (Report, error) {
+	c := &checker{
+		tx:      tx,
+		options: options,
+		repair:  options.Repair && tx.Writable(),
+
+		report: Report{
+			Namespaces: []NamespaceReport{},
+			Issues:     []Issue{},
+		},
+	}
+
+	steps := []func() error{
+		c.checkBuckets,
+		c.checkMeta,
+		c.checkOutcomes,
+		c.checkQueue(common.OutcomeQueueBucket),
+		c.checkQueue(common.OutcomeQueueBucket + ":dead"),
+		c.checkJSON(common.ContestsBucket),
+		c.checkJSON(common.APIKeysBucket),
+		c.checkJSON(common.ScorerVotersBucket),
+		c.checkJSON(common.ScorerQuarantineBucket),
+		c.checkJSON(common.ScorerPositionsBucket),
+		c.checkJSON(common.GoldBucket),
+	}
+
+	for _, namespace := range namespaces(tx) {
+		steps = append(steps,
+			c.enterNamespace(namespace),
+			c.checkHistory,
+			c.checkPairs,
+			c.checkBallots,
+			c.checkAssets,
+			c.checkRatingSum)
+	}
+
+	for _, step := range steps {
+		err := step()
+		if err != nil {
+			return Report{}, err
+		}
+	}
+
+	slices.SortStableFunc(c.report.Issues, func(a, b Issue) int {
+		return cmp.Or(cmp.Compare(a.Bucket, b.Bucket), cmp.Compare(a.Key, b.Key))
+	})
+
+	return c.report, nil
+}
+
+// namespaces returns the default ratings namespace and every namespace that
+// has at least one scorer bucket.
+func namespaces(tx *bolt.Tx) []string {
+	result := []string{""}
+
+	_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		for _, bucket := range namespacedBuckets {
+			namespace, ok := strings.CutPrefix(string(name), bucket+"@")
+			if ok && !slices.Contains(result, namespace) {
+				result = append(result, namespace)
+			}
+		}
+
+		return nil
+	})
+
+	return result
+}
+
+func (c *checker) enterNamespace(namespace string) func() error {
+	return func() error {
+		c.namespace = namespace
+		c.known = nil
+		c.records = map[string]common.AssetRecord{}
+		c.totals = map[string]*pairTotals{}
+
+		if known, ok := c.options.KnownAssets[namespace]; ok {
+			c.known = make(map[string]bool, len(known))
+
+			for _, assetID := range known {
+				c.known[assetID] = true
+			}
+		}
+
+		return nil
+	}
+}
+
+// bucket returns the name of a scorer bucket in the current namespace.
+func (c *checker) bucket(name string) string {
+	return string(common.NamespaceBucket(name, c.namespace))
+}
+
+func (c *checker) add(bucket, key, problem string, repaired bool) {
+	c.report.Issues = append(c.report.Issues, Issue{
+		Bucket:   bucket,
+		Key:      key,
+		Problem:  problem,
+		Repaired: repaired,
+	})
+}
+
+func (c *checker) checkBuckets() error {
+	required := []string{
+		common.MetaBucket,
+		common.ScorerAssetsBucket,
+		common.ScorerHistoryBucket,
+		common.ScorerPairsBucket,
+		common.ScorerOutcomesBucket,
+		common.ScorerVotersBucket,
+		common.ScorerBallotsBucket,
+		common.ScorerPositionsBucket,
+		common.ScorerQuarantineBucket,
+		common.ContestsBucket,
+		common.GoldBucket,
+		common.APIKeysBucket,
+	}
+
+	// The queue buckets are created by the server on its first start.
+	expected := append(slices.Clone(required), common.OutcomeQueueBucket, common.OutcomeQueueBucket+":dead")
+
+	for _, bucket := range required {
+		if c.tx.Bucket([]byte(bucket)) != nil {
+			continue
+		}
+
+		if c.repair {
+			_, err := c.tx.CreateBucket([]byte(bucket))
+			if err != nil {
+				return fmt.Errorf("failed to create %s bucket: %w", bucket, err)
+			}
+		}
+
+		c.add(bucket, "", "bucket is missing", c.repair)
+	}
+
+	//nolint:wrapcheck
+	return c.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		bucket, _, _ := strings.Cut(string(name), "@")
+
+		if !slices.Contains(expected, bucket) || (bucket != string(name) && !slices.Contains(namespacedBuckets, bucket)) {
+			c.add(string(name), "", "unknown bucket", false)
+		}
+
+		return nil
+	})
+}
+
+func (c *checker) checkMeta() error {
+	meta := c.tx.Bucket([]byte(common.MetaBucket))
+	if meta == nil {
+		return nil
+	}
+
+	value := meta.Get([]byte(common.SchemaVersionKey))
+
+	switch {
+	case len(value) != 8:
+		c.add(common.MetaBucket, common.SchemaVersionKey, fmt.Sprintf("expected 8 bytes, got %d", len(value)), false)
+	case common.SchemaVersion(c.tx) != common.LatestSchemaVersion():
+		c.add(common.MetaBucket, common.SchemaVersionKey,
+			fmt.Sprintf("schema version %d, expected %d", common.SchemaVersion(c.tx), common.LatestSchemaVersion()), false)
+	}
+
+	return nil
+}
+
+// checkNested checks the nested per-asset buckets of bucket and removes
+// entries rejected by valid, or values stored directly in bucket.
+//
+//nolint:cyclop // Collecting and removing bad keys requires this complexity
+func (c *checker) checkNested(bucket string, valid func(k, v []byte) string, f func(assetID string, k, v []byte)) error {
+	parent := c.tx.Bucket([]byte(bucket))
+	if parent == nil {
+		return nil
+	}
+
+	values := [][]byte{}
+	assetIDs := [][]byte{}
+
+	err := parent.ForEach(func(k, v []byte) error {
+		if v != nil {
+			values = append(values, k)
+		} else {
+			assetIDs = append(assetIDs, k)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", bucket, err)
+	}
+
+	for _, k := range values {
+		c.add(bucket, string(k), "value instead of a nested bucket", c.repair)
+
+		if c.repair {
+			err := parent.Delete(k)
+			if err != nil {
+				return fmt.Errorf("failed to delete %s from %s: %w", k, bucket, err)
+			}
+		}
+	}
+
+	for _, assetID := range assetIDs {
+		nested := parent.Bucket(assetID)
+		bad := [][]byte{}
+
+		err := nested.ForEach(func(k, v []byte) error {
+			problem := valid(k, v)
+			if problem != "" {
+				c.add(bucket, fmt.Sprintf("%s/%x", assetID, k), problem, c.repair)
+
+				bad = append(bad, k)
+
+				return nil
+			}
+
+			f(string(assetID), k, v)
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read %s of %s: %w", bucket, assetID, err)
+		}
+
+		if !c.repair {
+			continue
+		}
+
+		for _, k := range bad {
+			err := nested.Delete(k)
+			if errors.Is(err, berrors.ErrIncompatibleValue) {
+				err = nested.DeleteBucket(k)
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to delete %x from %s of %s: %w", k, bucket, assetID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) checkHistory() error {
+	return c.checkNested(c.bucket(common.ScorerHistoryBucket), func(k, v []byte) string {
+		switch {
+		case len(k) != 8:
+			return fmt.Sprintf("expected an 8 byte key, got %d", len(k))
+		case v == nil:
+			return "nested bucket in history"
+		case len(v) != scorer.HistoryEntrySize:
+			return fmt.Sprintf("expected %d bytes, got %d", scorer.HistoryEntrySize, len(v))
+		}
+
+		return ""
+	}, func(assetID string, _, _ []byte) {
+		c.totalsOf(assetID)
+	})
+}
+
+func (c *checker) checkPairs() error {
+	pairs := map[[2]string][3]int64{}
+
+	err := c.checkNested(c.bucket(common.ScorerPairsBucket), func(_, v []byte) string {
+		switch {
+		case v == nil:
+			return "nested bucket in pairs"
+		case len(v) != scorer.PairRecordSize:
+			return fmt.Sprintf("expected %d bytes, got %d", scorer.PairRecordSize, len(v))
+		}
+
+		return ""
+	}, func(assetID string, k, v []byte) {
+		//nolint:gosec // Intentional conversion from binary encoding
+		record := [3]int64{
+			int64(binary.LittleEndian.Uint64(v[0:8])),
+			int64(binary.LittleEndian.Uint64(v[8:16])),
+			int64(binary.LittleEndian.Uint64(v[16:24])),
+		}
+
+		pairs[[2]string{assetID, string(k)}] = record
+
+		totals := c.totalsOf(assetID)
+		totals.wins += record[0]
+		totals.losses += record[1]
+		totals.ties += record[2]
+	})
+	if err != nil {
+		return err
+	}
+
+	for key, record := range pairs {
+		mirrored, ok := pairs[[2]string{key[1], key[0]}]
+
+		switch {
+		case !ok:
+			c.add(c.bucket(common.ScorerPairsBucket), key[0]+"/"+key[1], "opponent has no mirrored record", false)
+		case key[0] < key[1] && mirrored != [3]int64{record[1], record[0], record[2]}:
+			c.add(c.bucket(common.ScorerPairsBucket), key[0]+"/"+key[1], "record does not mirror the opponent's record", false)
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) totalsOf(assetID string) *pairTotals {
+	totals, ok := c.totals[assetID]
+	if !ok {
+		totals = &pairTotals{}
+		c.totals[assetID] = totals
+	}
+
+	return totals
+}
+
+//nolint:cyclop // Every record is checked against several invariants
+func (c *checker) checkAssets() error {
+	bucket := c.bucket(common.ScorerAssetsBucket)
+
+	assets := c.tx.Bucket([]byte(bucket))
+	if assets == nil {
+		if len(c.totals) == 0 || !c.repair {
+			return nil
+		}
+
+		var err error
+
+		assets, err = c.tx.CreateBucket([]byte(bucket))
+		if err != nil {
+			return fmt.Errorf("failed to create %s bucket: %w", bucket, err)
+		}
+	}
+
+	rebuild := map[string]bool{}
+
+	err := assets.ForEach(func(k, v []byte) error {
+		assetID := string(k)
+
+		if c.known != nil && !c.known[assetID] {
+			c.add(bucket, assetID, "unknown asset", false)
+		}
+
+		record, err := common.DecodeAssetRecord(v)
+		if err != nil {
+			c.add(bucket, assetID, err.Error(), c.repair)
+			rebuild[assetID] = true
+
+			return nil
+		}
+
+		totals := c.totalsOf(assetID)
+
+		// Databases upgraded from before head-to-head records only know
+		// how many games an asset played, so the counters can only be
+		// checked against pairs that exist, and games may exceed the sum
+		// of wins and losses.
+		switch {
+		case !isFinite(record.Rating):
+			c.add(bucket, assetID, "rating is not a finite number", c.repair)
+			rebuild[assetID] = true
+		case totals.wins+totals.losses+totals.ties > 0 &&
+			(record.Wins != totals.wins || record.Losses != totals.losses || record.Ties != totals.ties):
+			c.add(bucket, assetID, "wins, losses or ties disagree with the head-to-head records", c.repair)
+			rebuild[assetID] = true
+		case record.Games < record.Wins+record.Losses:
+			c.add(bucket, assetID, "games are fewer than wins and losses", c.repair)
+			rebuild[assetID] = true
+		}
+
+		c.records[assetID] = record
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", bucket, err)
+	}
+
+	for assetID := range c.totals {
+		if _, ok := c.records[assetID]; ok || rebuild[assetID] {
+			continue
+		}
+
+		c.add(bucket, assetID, "asset has history or head-to-head records but no record", c.repair)
+		rebuild[assetID] = true
+	}
+
+	if !c.repair {
+		return nil
+	}
+
+	for assetID := range rebuild {
+		record := c.rebuildRecord(assetID)
+
+		err := assets.Put([]byte(assetID), common.EncodeAssetRecord(record))
+		if err != nil {
+			return fmt.Errorf("failed to put record of %s: %w", assetID, err)
+		}
+
+		c.records[assetID] = record
+	}
+
+	return nil
+}
+
+// rebuildRecord reconstructs the record of an asset. The counters come from
+// the head-to-head records. A finite rating that could still be decoded is
+// kept along with its timestamps, otherwise both come from the history.
+func (c *checker) rebuildRecord(assetID string) common.AssetRecord {
+	totals := c.totalsOf(assetID)
+
+	record := common.AssetRecord{
+		Rating:    scorer.DefaultRating,
+		Deviation: scorer.DefaultDeviation,
+		Games:     totals.wins + totals.losses,
+		Wins:      totals.wins,
+		Losses:    totals.losses,
+		Ties:      totals.ties,
+	}
+
+	previous, ok := c.records[assetID]
+	if ok && previous.Deviation > 0 {
+		record.Deviation = previous.Deviation
+	}
+
+	if ok && isFinite(previous.Rating) {
+		record.Rating = previous.Rating
+		record.Games = max(record.Games, previous.Games)
+		record.FirstSeen = previous.FirstSeen
+		record.LastUpdated = previous.LastUpdated
+
+		return record
+	}
+
+	history, err := scorer.LoadHistory(c.tx, c.namespace, assetID)
+	if err == nil && len(history) > 0 {
+		record.Rating = history[len(history)-1].Rating
+		record.FirstSeen = history[0].Timestamp
+		record.LastUpdated = history[len(history)-1].Timestamp
+	}
+
+	return record
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func (c *checker) checkOutcomes() error {
+	outcomes := c.tx.Bucket([]byte(common.ScorerOutcomesBucket))
+	if outcomes == nil {
+		return nil
+	}
+
+	bad := [][]byte{}
+
+	err := outcomes.ForEach(func(k, v []byte) error {
+		if len(v) < 8 {
+			c.add(common.ScorerOutcomesBucket, string(k), fmt.Sprintf("expected at least 8 bytes, got %d", len(v)), c.repair)
+
+			bad = append(bad, k)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", common.ScorerOutcomesBucket, err)
+	}
+
+	if !c.repair {
+		return nil
+	}
+
+	// The outcome was applied, only when is unknown.
+	for _, k := range bad {
+		err := outcomes.Put(k, common.Int64ToBytes(0))
+		if err != nil {
+			return fmt.Errorf("failed to put outcome key: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) checkQueue(bucket string) func() error {
+	return func() error {
+		queue := c.tx.Bucket([]byte(bucket))
+		if queue == nil {
+			return nil
+		}
+
+		bad := [][]byte{}
+
+		err := queue.ForEach(func(k, v []byte) error {
+			switch {
+			case len(k) != 8:
+				c.add(bucket, fmt.Sprintf("%x", k), fmt.Sprintf("expected an 8 byte key, got %d", len(k)), c.repair)
+			case !json.Valid(v):
+				c.add(bucket, fmt.Sprintf("%x", k), "value is not valid JSON", c.repair)
+			default:
+				return nil
+			}
+
+			bad = append(bad, k)
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", bucket, err)
+		}
+
+		if !c.repair {
+			return nil
+		}
+
+		for _, k := range bad {
+			err := queue.Delete(k)
+			if err != nil {
+				return fmt.Errorf("failed to delete %x from %s: %w", k, bucket, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func (c *checker) checkJSON(bucket string) func() error {
+	return func() error {
+		values := c.tx.Bucket([]byte(bucket))
+		if values == nil {
+			return nil
+		}
+
+		//nolint:wrapcheck
+		return values.ForEach(func(k, v []byte) error {
+			if !json.Valid(v) {
+				c.add(bucket, string(k), "value is not valid JSON", false)
+			}
+
+			return nil
+		})
+	}
+}
+
+func (c *checker) checkBallots() error {
+	return c.checkJSON(c.bucket(common.ScorerBallotsBucket))()
+}
+
+func (c *checker) checkRatingSum() error {
+	summary := NamespaceReport{
+		Namespace:  c.namespace,
+		Assets:     len(c.records),
+		MeanRating: scorer.DefaultRating,
+	}
+
+	if len(c.records) == 0 {
+		c.report.Namespaces = append(c.report.Namespaces, summary)
+
+		return nil
+	}
+
+	sum := 0.0
+
+	for _, record := range c.records {
+		sum += record.Rating
+	}
+
+	summary.MeanRating = sum / float64(len(c.records))
+	c.report.Namespaces = append(c.report.Namespaces, summary)
+
+	tolerance := c.options.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	drift := summary.MeanRating - scorer.DefaultRating
+	if math.Abs(drift) > tolerance {
+		c.add(c.bucket(common.ScorerAssetsBucket), "",
+			fmt.Sprintf("mean rating drifted by %.2f from %.0f", drift, scorer.DefaultRating), false)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package integrity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/common"
+	integrity "github.com/vreid/shiki/internal/pkg/integrity"
+	"github.com/vreid/shiki/internal/pkg/matchmaker"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+// openScoredDatabase opens a database with three assets that played three
+// games against each other.
+func openScoredDatabase(t *testing.T) *bolt.DB {
+	t.Helper()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucket([]byte(common.OutcomeQueueBucket))
+		require.NoError(t, err)
+
+		_, err = tx.CreateBucket([]byte(common.OutcomeQueueBucket + ":dead"))
+
+		return err
+	}))
+
+	for idx, winnerID := range []string{"o-1", "o-2", ""} {
+		outcome := matchmaker.Outcome{
+			WinnerID: winnerID,
+			SignedMatchUp: matchmaker.SignedMatchUp{
+				MatchUp: matchmaker.MatchUp{
+					Opponents: []matchmaker.Opponent{
+						{OpponentID: "o-1", AssetID: "a-1"},
+						{OpponentID: "o-2", AssetID: "a-2"},
+						{OpponentID: "o-3", AssetID: "a-3"},
+					},
+				},
+				Signature: string(rune('a' + idx)),
+			},
+		}
+
+		require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+			return scorer.ApplyOutcome(tx, outcome, scorer.Options{})
+		}))
+	}
+
+	return db
+}
+
+func check(t *testing.T, db *bolt.DB, options integrity.Options) integrity.Report {
+	t.Helper()
+
+	var report integrity.Report
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		var err error
+
+		report, err = integrity.Check(tx, options)
+
+		return err
+	}))
+
+	return report
+}
+
+func TestCheckClean(t *testing.T) {
+	t.Parallel()
+
+	db := openScoredDatabase(t)
+
+	report := check(t, db, integrity.Options{KnownAssets: map[string][]string{"": {"a-1", "a-2", "a-3"}}})
+
+	assert.Equal(t, []integrity.NamespaceReport{{Assets: 3, MeanRating: 1500.0}}, report.Namespaces)
+	assert.Empty(t, report.Issues)
+}
+
+func TestCheckRepair(t *testing.T) {
+	t.Parallel()
+
+	db := openScoredDatabase(t)
+
+	var expected, broken common.AssetRecord
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		assets := tx.Bucket([]byte(common.ScorerAssetsBucket))
+
+		var err error
+
+		expected, err = scorer.LoadRecord(assets, "a-1")
+		require.NoError(t, err)
+
+		require.NoError(t, assets.Put([]byte("a-1"), []byte{1, 2, 3}))
+
+		broken, err = scorer.LoadRecord(assets, "a-2")
+		require.NoError(t, err)
+
+		broken.Wins += 5
+		require.NoError(t, assets.Put([]byte("a-2"), common.EncodeAssetRecord(broken)))
+
+		require.NoError(t, tx.Bucket([]byte(common.ScorerOutcomesBucket)).Put([]byte("x"), []byte{1}))
+		require.NoError(t, tx.Bucket([]byte(common.OutcomeQueueBucket)).Put(common.Int64ToBytes(1), []byte("{")))
+
+		history, err := tx.Bucket([]byte(common.ScorerHistoryBucket)).CreateBucket([]byte("a-4"))
+		require.NoError(t, err)
+
+		entry := append(common.Int64ToBytes(1760000000), common.Float64ToBytes(1500.0)...)
+		require.NoError(t, history.Put(common.Int64ToBytes(1), append(entry, common.Int64ToBytes(0)...)))
+
+		return history.Put(common.Int64ToBytes(2), []byte{1})
+	}))
+
+	options := integrity.Options{KnownAssets: map[string][]string{"": {"a-1", "a-2", "a-3"}}}
+
+	// a-1, a-2, a-4 twice, the outcome, the queue entry and the rating drift.
+	report := check(t, db, options)
+	assert.Len(t, report.Issues, 7)
+	assert.Equal(t, 7, report.Unrepaired())
+
+	options.Repair = true
+
+	report = check(t, db, options)
+	assert.Len(t, report.Issues, 6)
+	assert.Equal(t, 0, report.Unrepaired())
+	assert.InDelta(t, 1500.0, report.Namespaces[0].MeanRating, 0.0001)
+
+	options.Repair = false
+
+	report = check(t, db, options)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, integrity.Issue{Bucket: common.ScorerAssetsBucket, Key: "a-4", Problem: "unknown asset"}, report.Issues[0])
+
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		record, err := scorer.LoadRecord(tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-1")
+		require.NoError(t, err)
+		assert.InDelta(t, expected.Rating, record.Rating, 0.0001)
+		assert.Equal(t, expected.Games, record.Games)
+		assert.Equal(t, expected.Wins, record.Wins)
+		assert.Equal(t, expected.Ties, record.Ties)
+
+		record, err = scorer.LoadRecord(tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-2")
+		require.NoError(t, err)
+		assert.InDelta(t, broken.Rating, record.Rating, 0.0001)
+		assert.Equal(t, broken.Wins-5, record.Wins)
+
+		return nil
+	}))
+}
+
+func TestCheckRepairBaseline(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenDatabase(t)
+	// Databases from before schema versions only counted games.
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		ratings, err := tx.CreateBucket([]byte("scorer:ratings"))
+		require.NoError(t, err)
+
+		count, err := tx.CreateBucket([]byte("scorer:count"))
+		require.NoError(t, err)
+
+		require.NoError(t, ratings.Put([]byte("a-1"), common.Float64ToBytes(1600.0)))
+		require.NoError(t, count.Put([]byte("a-1"), common.Int64ToBytes(10)))
+		require.NoError(t, ratings.Put([]byte("a-2"), common.Float64ToBytes(1400.0)))
+
+		return count.Put([]byte("a-2"), common.Int64ToBytes(10))
+	}))
+
+	_, err := common.Migrate(db, false)
+	require.NoError(t, err)
+
+	report := check(t, db, integrity.Options{Repair: true})
+	assert.Empty(t, report.Issues)
+
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		assets := tx.Bucket([]byte(common.ScorerAssetsBucket))
+
+		for assetID, rating := range map[string]float64{"a-1": 1600.0, "a-2": 1400.0} {
+			record, err := scorer.LoadRecord(assets, assetID)
+			require.NoError(t, err)
+			assert.InDelta(t, rating, record.Rating, 0.0001)
+			assert.Equal(t, int64(10), record.Games)
+		}
+
+		return nil
+	}))
+}
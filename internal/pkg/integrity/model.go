@@ -0,0 +1,35 @@
+package integrity
+
+type Issue struct {
+	Bucket  string `json:"bucket"`
+	Key     string `json:"key,omitempty"`
+	Problem string `json:"problem"`
+
+	Repaired bool `json:"repaired"`
+}
+
+type NamespaceReport struct {
+	Namespace string `json:"namespace"`
+
+	Assets     int     `json:"assets"`
+	MeanRating float64 `json:"mean_rating"`
+}
+
+type Report struct {
+	Namespaces []NamespaceReport `json:"namespaces"`
+
+	Issues []Issue `json:"issues"`
+}
+
+// Unrepaired returns the number of issues that are still present.
+func (r Report) Unrepaired() int {
+	result := 0
+
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			result++
+		}
+	}
+
+	return result
+}
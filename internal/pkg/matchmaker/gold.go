@@ -0,0 +1,69 @@
+package matchmaker
+
+import (
+	"fmt"
+
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/gold"
+	bolt "go.etcd.io/bbolt"
+)
+
+// goldPrecision is the resolution the gold rate is drawn with.
+const goldPrecision = 1 << 20
+
+// PickGoldOpponents returns the assets of a random gold pair that fits the
+// contest, or nil if there is none.
+func PickGoldOpponents(pairs []gold.Pair, current contest.Contest) ([]string, error) {
+	candidates := []gold.Pair{}
+
+	for _, pair := range pairs {
+		if len(pair.Assets) == current.Opponents {
+			candidates = append(candidates, pair)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	idx, err := randomIndex(len(candidates))
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{}, candidates[idx].Assets...), nil
+}
+
+// pickOpponents returns the opponents of a new match-up. Every so often
+// they are a gold pair, which looks like any other match-up to the voter.
+func (s *MatchmakerService) pickOpponents(current contest.Contest, template MatchUp) ([]string, error) {
+	if s.GoldRate > 0 {
+		draw, err := randomIndex(goldPrecision)
+		if err != nil {
+			return nil, err
+		}
+
+		if float64(draw) < s.GoldRate*goldPrecision {
+			var pairs []gold.Pair
+
+			namespace := template.Namespace()
+
+			err = s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+				pairs, err = gold.List(tx, &namespace)
+
+				//nolint:wrapcheck
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list gold pairs: %w", err)
+			}
+
+			opponents, err := PickGoldOpponents(pairs, current)
+			if err != nil || opponents != nil {
+				return opponents, err
+			}
+		}
+	}
+
+	return PickRandomOpponents(current.Assets, current.Opponents)
+}
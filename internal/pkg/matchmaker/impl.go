@@ -10,11 +10,16 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/auth"
 	"github.com/vreid/shiki/internal/pkg/common"
-	"github.com/vreid/shiki/internal/pkg/metadata"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/queue"
+	"github.com/vreid/shiki/internal/pkg/ratelimit"
+	"github.com/vreid/shiki/internal/pkg/voter"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -22,33 +27,58 @@ import (
 
 var ErrNotEnoughAssets = errors.New("not enough assets available to pick opponents")
 
+// QueueFullRetryAfter is the delay clients are asked to wait before posting
+// an outcome again when the outcome queue is full.
+const QueueFullRetryAfter = 5 * time.Second
+
 type MatchmakerService struct {
-	OutcomeSink chan<- Outcome
+	DatabaseService *common.DatabaseService
+	ContestService  *contest.ContestService
+	VoterService    *voter.VoterService
+	OutcomeQueue    *queue.Queue[Outcome]
 
 	SignatureSecret string
 
-	BaseDifficulty     int
-	Opponents          int
 	TokenMaxAgeMinutes int
+
+	// GoldRate is the share of match-ups served from the gold pairs.
+	GoldRate float64
+
+	// MinDeliberation is how long voters have to look at a match-up before
+	// posting its outcome.
+	MinDeliberation time.Duration
 }
 
 func NewMatchmakerService(i do.Injector) (*MatchmakerService, error) {
-	outcomeSink := do.MustInvokeNamed[chan<- Outcome](i, "outcome-sink")
+	databaseService := do.MustInvoke[*common.DatabaseService](i)
+	contestService := do.MustInvoke[*contest.ContestService](i)
+	outcomeQueue := do.MustInvoke[*queue.Queue[Outcome]](i)
+	authService := do.MustInvoke[*auth.AuthService](i)
+	rateLimitService := do.MustInvoke[*ratelimit.RateLimitService](i)
+
+	// Match-ups are bound to the voter ID the voter service puts in the
+	// context.
+	voterService := do.MustInvoke[*voter.VoterService](i)
 
 	signatureSecret := do.MustInvokeNamed[string](i, "signature-secret")
 
-	baseDifficulty := do.MustInvokeNamed[int](i, "base-difficulty")
-	opponents := do.MustInvokeNamed[int](i, "opponents")
 	tokenMaxAgeMinutes := do.MustInvokeNamed[int](i, "token-max-age-minutes")
+	goldRate := do.MustInvokeNamed[float64](i, "gold-rate")
+	minDeliberationMs := do.MustInvokeNamed[int](i, "min-deliberation-ms")
 
 	result := &MatchmakerService{
-		OutcomeSink: outcomeSink,
+		DatabaseService: databaseService,
+		ContestService:  contestService,
+		VoterService:    voterService,
+		OutcomeQueue:    outcomeQueue,
 
 		SignatureSecret: signatureSecret,
 
-		BaseDifficulty:     baseDifficulty,
-		Opponents:          opponents,
 		TokenMaxAgeMinutes: tokenMaxAgeMinutes,
+
+		GoldRate: goldRate,
+
+		MinDeliberation: time.Duration(minDeliberationMs) * time.Millisecond,
 	}
 
 	echoService, err := do.Invoke[*common.EchoService](i)
@@ -59,24 +89,50 @@ func NewMatchmakerService(i do.Injector) (*MatchmakerService, error) {
 	echoService.Register(func(e *echo.Echo) {
 		apiGroup := e.Group("/api")
 
-		matchmakerGroup := apiGroup.Group("/matchmaker")
+		limitMatchUp := rateLimitService.MatchUp.Middleware()
+		limitOutcome := rateLimitService.Outcome.Middleware()
+
+		matchmakerGroup := apiGroup.Group("/matchmaker", authService.RequireVoter())
+
+		matchmakerGroup.GET("/match-up", result.GetMatchUp, limitMatchUp)
+		matchmakerGroup.POST("/outcome", result.PostOutcome, limitOutcome)
 
-		matchmakerGroup.GET("/match-up", result.GetMatchUp)
-		matchmakerGroup.POST("/outcome", result.PostOutcome)
+		contestGroup := apiGroup.Group("/contests/:contestID/matchmaker", authService.RequireVoter())
+
+		contestGroup.GET("/match-up", result.GetMatchUp, limitMatchUp)
+		contestGroup.POST("/outcome", result.PostOutcome, limitOutcome)
 	})
 
 	return result, nil
 }
 
-func CreateMatchUp(opponents []string, signatureSecret []byte, difficulty int) (*SignedMatchUp, error) {
-	timestamp := time.Now().Unix()
+func NewOutcomeQueue(i do.Injector) (*queue.Queue[Outcome], error) {
+	databaseService := do.MustInvoke[*common.DatabaseService](i)
+	queueCapacity := do.MustInvokeNamed[int](i, "queue-capacity")
+
+	result, err := queue.NewQueue[Outcome](databaseService.DB, common.OutcomeQueueBucket, queueCapacity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create outcome queue: %w", err)
+	}
+
+	return result, nil
+}
 
-	matchUp := MatchUp{
-		Opponents:  []Opponent{},
-		Timestamp:  timestamp,
-		Difficulty: difficulty,
+// CreateMatchUp adds the opponents in random order and the current time to a
+// match-up and signs it. Clients show the opponents in the order of
+// MatchUp.Opponents, so the signature also covers the presentation order.
+func CreateMatchUp(matchUp MatchUp, opponents []string, signatureSecret []byte) (*SignedMatchUp, error) {
+	opponents, err := Shuffle(opponents)
+	if err != nil {
+		return nil, err
 	}
 
+	matchUp.Opponents = []Opponent{}
+	now := time.Now()
+
+	matchUp.Timestamp = now.Unix()
+	matchUp.IssuedAtMs = now.UnixMilli()
+
 	for _, assetID := range opponents {
 		candidateID, err := uuid.NewRandom()
 		if err != nil {
@@ -112,20 +168,50 @@ func ComputeHash(outcome Outcome) string {
 		outcome.WinnerID,
 		outcome.Nonce)
 
+	// Ties are part of the proof, without changing it for other outcomes.
+	if outcome.Tie {
+		message += "|tie"
+	}
+
 	h := sha256.New()
 	h.Write([]byte(message))
 
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-func PickRandomOpponents(x int) ([]string, error) {
-	if x > len(metadata.Assets) {
-		return nil, fmt.Errorf("%w: cannot pick %d opponents from %d assets", ErrNotEnoughAssets, x, len(metadata.Assets))
+func randomIndex(n int) (int, error) {
+	idx, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random index: %w", err)
+	}
+
+	return int(idx.Int64()), nil
+}
+
+// Shuffle returns the asset IDs in uniformly random order.
+func Shuffle(assetIDs []string) ([]string, error) {
+	result := append([]string{}, assetIDs...)
+
+	for i := len(result) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result, nil
+}
+
+func PickRandomOpponents(assets []string, x int) ([]string, error) {
+	if x > len(assets) {
+		return nil, fmt.Errorf("%w: cannot pick %d opponents from %d assets", ErrNotEnoughAssets, x, len(assets))
 	}
 
 	candidates := make(map[string]bool, x)
 
-	maxIdx := big.NewInt(int64(len(metadata.Assets)))
+	maxIdx := big.NewInt(int64(len(assets)))
 	for len(candidates) < x {
 		randIdx, err := rand.Int(rand.Reader, maxIdx)
 		if err != nil {
@@ -133,7 +219,7 @@ func PickRandomOpponents(x int) ([]string, error) {
 		}
 
 		idx := int(randIdx.Int64())
-		candidates[metadata.Assets[idx]] = true
+		candidates[assets[idx]] = true
 	}
 
 	result := make([]string, 0, x)
@@ -144,6 +230,43 @@ func PickRandomOpponents(x int) ([]string, error) {
 	return result, nil
 }
 
+// PickCriterion returns the criterion targeted by the request, or rotates
+// randomly through the criteria of the contest. The zero criterion is
+// returned for contests without criteria.
+func PickCriterion(c echo.Context, current contest.Contest) (contest.Criterion, error) {
+	result, err := contest.CriterionFromContext(c, current)
+	if err != nil || result.ID != "" || len(current.Criteria) == 0 {
+		//nolint:wrapcheck
+		return result, err
+	}
+
+	randIdx, err := rand.Int(rand.Reader, big.NewInt(int64(len(current.Criteria))))
+	if err != nil {
+		return contest.Criterion{}, echo.NewHTTPError(http.StatusInternalServerError, "failed to pick criterion")
+	}
+
+	return current.Criteria[randIdx.Int64()], nil
+}
+
+// newMatchUp returns a match-up for the contest without opponents, bound to
+// the voter of the request.
+func newMatchUp(c echo.Context, current contest.Contest) (MatchUp, error) {
+	criterion, err := PickCriterion(c, current)
+	if err != nil {
+		return MatchUp{}, err
+	}
+
+	result := MatchUp{
+		ContestID:   current.Namespace(),
+		CriterionID: criterion.ID,
+		Prompt:      criterion.Prompt,
+		VoterID:     voter.FromContext(c),
+		Difficulty:  current.Difficulty,
+	}
+
+	return result, nil
+}
+
 func VerifyProof(outcome Outcome) bool {
 	computed := ComputeHash(outcome)
 
@@ -163,9 +286,18 @@ func VerifyProof(outcome Outcome) bool {
 }
 
 func (s *MatchmakerService) GetMatchUp(c echo.Context) error {
-	difficulty := 0
+	contest, err := s.ContestService.FromContext(c)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
 
-	opponents, err := PickRandomOpponents(s.Opponents)
+	template, err := newMatchUp(c, contest)
+	if err != nil {
+		return err
+	}
+
+	opponents, err := s.pickOpponents(contest, template)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to pick opponents")
 	}
@@ -174,7 +306,7 @@ func (s *MatchmakerService) GetMatchUp(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusTooEarly, "not enough assets available")
 	}
 
-	matchUp, err := CreateMatchUp(opponents, []byte(s.SignatureSecret), difficulty)
+	matchUp, err := CreateMatchUp(template, opponents, []byte(s.SignatureSecret))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create match-up")
 	}
@@ -192,6 +324,9 @@ func (s *MatchmakerService) PostOutcome(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
 
+	outcome.ReceivedAtMs = time.Now().UnixMilli()
+	outcome.TimingFlagged = false
+
 	marshaledMatchUp, err := json.Marshal(outcome.SignedMatchUp.MatchUp)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid match-up")
@@ -215,7 +350,41 @@ func (s *MatchmakerService) PostOutcome(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid proof of work")
 	}
 
+	contest, err := s.ContestService.FromContext(c)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	if outcome.SignedMatchUp.MatchUp.ContestID != contest.Namespace() {
+		return echo.NewHTTPError(http.StatusBadRequest, "match-up belongs to a different contest")
+	}
+
+	// Anonymous voters may lose their cookie between match-up and outcome,
+	// so only authenticated voters are held to the binding.
+	if voterID := outcome.SignedMatchUp.MatchUp.VoterID; voter.Authenticated(voterID) && voterID != voter.FromContext(c) {
+		return echo.NewHTTPError(http.StatusForbidden, "match-up was issued to a different voter")
+	}
+
+	if outcome.DeliberationMs() < s.MinDeliberation.Milliseconds() {
+		return echo.NewHTTPError(http.StatusBadRequest, "outcome posted before the minimum deliberation time")
+	}
+
+	err = s.checkTiming(c, &outcome)
+	if err != nil {
+		return err
+	}
+
+	criterionID := outcome.SignedMatchUp.MatchUp.CriterionID
+	if _, ok := contest.Criterion(criterionID); criterionID != "" && !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "match-up has an unknown criterion")
+	}
+
 	winnerID := outcome.WinnerID
+	if outcome.Tie && len(winnerID) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "a tie can't have a winner")
+	}
+
 	if len(winnerID) > 0 {
 		validWinnder := false
 
@@ -232,7 +401,12 @@ func (s *MatchmakerService) PostOutcome(c echo.Context) error {
 		}
 	}
 
-	opponents, err := PickRandomOpponents(s.Opponents)
+	template, err := newMatchUp(c, contest)
+	if err != nil {
+		return err
+	}
+
+	opponents, err := s.pickOpponents(contest, template)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to pick opponents")
 	}
@@ -241,13 +415,20 @@ func (s *MatchmakerService) PostOutcome(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusTooEarly, "not enough assets available")
 	}
 
-	if s.OutcomeSink != nil {
-		s.OutcomeSink <- outcome
-	}
+	if s.OutcomeQueue != nil {
+		err = s.OutcomeQueue.Enqueue(outcome)
+		if errors.Is(err, queue.ErrQueueFull) {
+			c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(QueueFullRetryAfter.Seconds())))
 
-	difficulty := 0
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "outcome queue is full")
+		}
+
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to enqueue outcome")
+		}
+	}
 
-	matchUp, err := CreateMatchUp(opponents, []byte(s.SignatureSecret), difficulty)
+	matchUp, err := CreateMatchUp(template, opponents, []byte(s.SignatureSecret))
 	if err != nil {
 		return fmt.Errorf("failed to create match-up: %w", err)
 	}
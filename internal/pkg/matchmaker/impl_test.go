@@ -2,11 +2,27 @@ package matchmaker_test
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
 	matchmaker "github.com/vreid/shiki/internal/pkg/matchmaker"
+	"github.com/vreid/shiki/internal/pkg/metadata"
+	"github.com/vreid/shiki/internal/pkg/queue"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	"github.com/vreid/shiki/internal/pkg/voter"
+	bolt "go.etcd.io/bbolt"
 
 	"github.com/google/uuid"
 )
@@ -50,17 +66,184 @@ func createOutcome(matchUp *matchmaker.SignedMatchUp) (*matchmaker.Outcome, erro
 	}
 }
 
+// newTestServer serves the matchmaker routes of a service whose outcome queue
+// holds queueCapacity outcomes, with anonymous voting.
+func newTestServer(t *testing.T, queueCapacity int) (*echo.Echo, *matchmaker.MatchmakerService) {
+	t.Helper()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	outcomeQueue, err := queue.NewQueue[matchmaker.Outcome](db, common.OutcomeQueueBucket, queueCapacity)
+	require.NoError(t, err)
+
+	databaseService := &common.DatabaseService{DB: db}
+	authService := &auth.AuthService{DatabaseService: databaseService, AnonymousVoting: true}
+
+	service := &matchmaker.MatchmakerService{
+		DatabaseService: databaseService,
+		ContestService: &contest.ContestService{
+			DatabaseService: databaseService,
+			Default:         contest.Contest{ID: contest.DefaultContestID, Assets: []string{"a-1", "a-2", "a-3"}, Opponents: 2},
+		},
+		VoterService: &voter.VoterService{
+			CookieSecret: []byte("cookie-secret"),
+			Clients:      voter.NewClientTiming(),
+		},
+		OutcomeQueue: outcomeQueue,
+
+		SignatureSecret: "signature-secret",
+
+		TokenMaxAgeMinutes: 10,
+	}
+
+	e := echo.New()
+	e.Use(authService.Authenticate(), service.VoterService.Identify())
+
+	for _, prefix := range []string{"/api", "/api/contests/:contestID"} {
+		e.GET(prefix+"/matchmaker/match-up", service.GetMatchUp)
+		e.POST(prefix+"/matchmaker/outcome", service.PostOutcome)
+	}
+
+	return e, service
+}
+
+// serve sends a request with the given headers to e.
+func serve(e *echo.Echo, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// getOutcome fetches a match-up from path and returns a valid outcome for it.
+func getOutcome(t *testing.T, e *echo.Echo, path string, header http.Header) string {
+	t.Helper()
+
+	rec := serve(e, http.MethodGet, path, "", header)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var matchUp matchmaker.SignedMatchUp
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &matchUp))
+
+	outcome, err := createOutcome(&matchUp)
+	require.NoError(t, err)
+
+	body, err := json.Marshal(outcome)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestPostOutcomeQueueFull(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newTestServer(t, 1)
+
+	rec := serve(e, http.MethodPost, "/api/matchmaker/outcome", getOutcome(t, e, "/api/matchmaker/match-up", nil), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serve(e, http.MethodPost, "/api/matchmaker/outcome", getOutcome(t, e, "/api/matchmaker/match-up", nil), nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get(echo.HeaderRetryAfter))
+}
+
+func TestPostOutcomeDifferentContest(t *testing.T) {
+	t.Parallel()
+
+	e, service := newTestServer(t, 10)
+
+	require.NoError(t, service.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		return contest.Create(tx, contest.Contest{ID: "photos", Assets: []string{"p-1", "p-2"}, Opponents: 2})
+	}))
+
+	outcome := getOutcome(t, e, "/api/contests/photos/matchmaker/match-up", nil)
+
+	rec := serve(e, http.MethodPost, "/api/matchmaker/outcome", outcome, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "match-up belongs to a different contest")
+
+	rec = serve(e, http.MethodPost, "/api/contests/photos/matchmaker/outcome", outcome, nil)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestPostOutcomeDifferentVoter(t *testing.T) {
+	t.Parallel()
+
+	e, service := newTestServer(t, 10)
+
+	headers := make([]http.Header, 2)
+
+	require.NoError(t, service.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		for idx := range headers {
+			_, token, err := auth.Create(tx, fmt.Sprintf("voter-%d", idx), []auth.Scope{auth.ScopeUpload})
+			if err != nil {
+				return err
+			}
+
+			headers[idx] = http.Header{echo.HeaderAuthorization: {"Bearer " + token}}
+		}
+
+		return nil
+	}))
+
+	outcome := getOutcome(t, e, "/api/matchmaker/match-up", headers[0])
+
+	rec := serve(e, http.MethodPost, "/api/matchmaker/outcome", outcome, headers[1])
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "match-up was issued to a different voter")
+
+	rec = serve(e, http.MethodPost, "/api/matchmaker/outcome", outcome, headers[0])
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestPostOutcomeMinDeliberation(t *testing.T) {
+	t.Parallel()
+
+	e, service := newTestServer(t, 10)
+	service.MinDeliberation = time.Hour
+
+	rec := serve(e, http.MethodPost, "/api/matchmaker/outcome", getOutcome(t, e, "/api/matchmaker/match-up", nil), nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "outcome posted before the minimum deliberation time")
+}
+
+func TestPostOutcomeTiming(t *testing.T) {
+	t.Parallel()
+
+	e, service := newTestServer(t, 10)
+	service.VoterService.Timing = voter.TimingPolicy{MinMeanMs: 1000, Action: voter.TimingActionReject}
+
+	// Every request comes from the same client, which already voted
+	// instantly just short of enough times to be judged.
+	clientID := voter.ClientID(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder()))
+
+	for range voter.RecentVotes - 1 {
+		service.VoterService.Clients.Record(clientID, 0, time.Now())
+	}
+
+	rec := serve(e, http.MethodPost, "/api/matchmaker/outcome", getOutcome(t, e, "/api/matchmaker/match-up", nil), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "voting pattern looks automated")
+}
+
 func BenchmarkCreateMatchUpPostOutcome(b *testing.B) {
 	opponents := 3
 	signatureSecret := uuid.New().String()
 
 	for b.Loop() {
-		opponents, err := matchmaker.PickRandomOpponents(opponents)
+		opponents, err := matchmaker.PickRandomOpponents(metadata.Assets, opponents)
 		if err != nil {
 			b.Error(err)
 		}
 
-		matchUp, err := matchmaker.CreateMatchUp(opponents, []byte(signatureSecret), 0)
+		matchUp, err := matchmaker.CreateMatchUp(matchmaker.MatchUp{}, opponents, []byte(signatureSecret))
 		if err != nil {
 			b.Error(err)
 		}
@@ -75,3 +258,78 @@ func BenchmarkCreateMatchUpPostOutcome(b *testing.B) {
 		}
 	}
 }
+
+func TestPickCriterion(t *testing.T) {
+	t.Parallel()
+
+	current := contest.Contest{
+		Criteria: []contest.Criterion{
+			{ID: "aesthetics", Prompt: "Which is more aesthetic?"},
+			{ID: "sharpness", Prompt: "Which is sharper?"},
+		},
+	}
+
+	e := echo.New()
+
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/?criterion=sharpness", nil), httptest.NewRecorder())
+
+	criterion, err := matchmaker.PickCriterion(c, current)
+	require.NoError(t, err)
+	assert.Equal(t, current.Criteria[1], criterion)
+
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	criterion, err = matchmaker.PickCriterion(c, current)
+	require.NoError(t, err)
+	assert.Contains(t, current.Criteria, criterion)
+
+	criterion, err = matchmaker.PickCriterion(c, contest.Contest{})
+	require.NoError(t, err)
+	assert.Empty(t, criterion.ID)
+
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/?criterion=color", nil), httptest.NewRecorder())
+
+	var httpErr *echo.HTTPError
+
+	_, err = matchmaker.PickCriterion(c, current)
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.Code)
+}
+
+func TestMatchUpNamespace(t *testing.T) {
+	t.Parallel()
+
+	matchUp, err := matchmaker.CreateMatchUp(matchmaker.MatchUp{
+		ContestID:   "photos",
+		CriterionID: "sharpness",
+		Prompt:      "Which is sharper?",
+	}, []string{"a-1", "a-2"}, []byte("secret"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Which is sharper?", matchUp.MatchUp.Prompt)
+	assert.Equal(t, "photos#sharpness", matchUp.MatchUp.Namespace())
+
+	matchUp.MatchUp.ContestID = ""
+	assert.Equal(t, "#sharpness", matchUp.MatchUp.Namespace())
+
+	matchUp.MatchUp.CriterionID = ""
+	assert.Empty(t, matchUp.MatchUp.Namespace())
+}
+
+func TestDeliberationMs(t *testing.T) {
+	t.Parallel()
+
+	matchUp, err := matchmaker.CreateMatchUp(matchmaker.MatchUp{}, []string{"a-1", "a-2"}, []byte("secret"))
+	require.NoError(t, err)
+
+	outcome := matchmaker.Outcome{SignedMatchUp: *matchUp}
+	assert.Equal(t, int64(-1), outcome.DeliberationMs())
+
+	outcome.ReceivedAtMs = matchUp.MatchUp.IssuedAtMs + 1500
+	assert.Equal(t, int64(1500), outcome.DeliberationMs())
+
+	// Match-ups without IssuedAtMs fall back to the second they were issued.
+	outcome.SignedMatchUp.MatchUp.IssuedAtMs = 0
+	outcome.ReceivedAtMs = matchUp.MatchUp.Timestamp*1000 + 700
+	assert.Equal(t, int64(700), outcome.DeliberationMs())
+}
@@ -1,15 +1,54 @@
 package matchmaker
 
+import "github.com/vreid/shiki/internal/pkg/contest"
+
 type Opponent struct {
 	OpponentID string `json:"opponent_id"`
 	AssetID    string `json:"asset_id"`
 }
 
 type MatchUp struct {
+	ContestID string `json:"contest_id,omitempty"`
+
+	CriterionID string `json:"criterion_id,omitempty"`
+	Prompt      string `json:"prompt,omitempty"`
+
+	// VoterID binds the match-up to the voter it was issued to.
+	VoterID string `json:"voter_id,omitempty"`
+
 	Opponents []Opponent `json:"opponents"`
 
 	Timestamp  int64 `json:"timestamp"`
 	Difficulty int   `json:"difficulty"`
+
+	// IssuedAtMs is Timestamp in milliseconds, for timing votes.
+	IssuedAtMs int64 `json:"issued_at_ms,omitempty"`
+}
+
+// Namespace returns the ratings namespace the outcome of the match-up is
+// scored in.
+func (m MatchUp) Namespace() string {
+	return contest.CriterionNamespace(m.ContestID, m.CriterionID)
+}
+
+// IssuedAt returns when the match-up was issued in milliseconds. Match-ups
+// issued before IssuedAtMs existed only know the second.
+func (m MatchUp) IssuedAt() int64 {
+	if m.IssuedAtMs > 0 {
+		return m.IssuedAtMs
+	}
+
+	return m.Timestamp * 1000
+}
+
+// DeliberationMs returns the time between issuing the match-up and
+// receiving the outcome, or -1 when unknown.
+func (o Outcome) DeliberationMs() int64 {
+	if o.ReceivedAtMs <= 0 {
+		return -1
+	}
+
+	return max(o.ReceivedAtMs-o.SignedMatchUp.MatchUp.IssuedAt(), 0)
 }
 
 type SignedMatchUp struct {
@@ -23,8 +62,19 @@ type Outcome struct {
 
 	WinnerID string `json:"winner_id"`
 
+	// Tie declares that no opponent won. Outcomes without a winner that
+	// aren't ties are skipped.
+	Tie bool `json:"tie,omitempty"`
+
 	Nonce int    `json:"nonce"`
 	Hash  string `json:"hash"`
+
+	// ReceivedAtMs is set by the server when the outcome is posted.
+	ReceivedAtMs int64 `json:"received_at_ms,omitempty"`
+
+	// TimingFlagged is set by the server when the timing of an anonymous
+	// client looks automated.
+	TimingFlagged bool `json:"timing_flagged,omitempty"`
 }
 
 type VerifiedOutcome struct {
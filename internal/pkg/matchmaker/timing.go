@@ -0,0 +1,63 @@
+package matchmaker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vreid/shiki/internal/pkg/voter"
+	bolt "go.etcd.io/bbolt"
+)
+
+// checkTiming logs voters whose recent votes look automated and rejects
+// their outcomes if the timing policy says so. Authenticated voters are
+// judged on their stored statistics, anonymous ones on the recent votes of
+// their client, since a script without cookies is a new voter every time.
+func (s *MatchmakerService) checkTiming(c echo.Context, outcome *Outcome) error {
+	policy := s.VoterService.Timing
+	if policy.MinMeanMs <= 0 && policy.MinCV <= 0 {
+		return nil
+	}
+
+	var stats voter.Stats
+
+	if voterID := outcome.SignedMatchUp.MatchUp.VoterID; voter.Authenticated(voterID) {
+		err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+			var err error
+
+			stats, err = voter.Load(tx, voterID)
+
+			//nolint:wrapcheck
+			return err
+		})
+		if errors.Is(err, voter.ErrVoterNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to load voter: %w", err)
+		}
+	} else {
+		stats = s.VoterService.Clients.Record(voter.ClientID(c), outcome.DeliberationMs(), time.Now())
+	}
+
+	reason := policy.Check(stats)
+	if reason == "" {
+		return nil
+	}
+
+	log.Printf("voter %s looks automated: %s", stats.VoterID, reason)
+
+	switch policy.Action {
+	case voter.TimingActionReject:
+		return echo.NewHTTPError(http.StatusForbidden, "voting pattern looks automated")
+	case voter.TimingActionDiscount:
+		outcome.TimingFlagged = true
+	case voter.TimingActionLog:
+	}
+
+	return nil
+}
@@ -0,0 +1,302 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/common"
+)
+
+const (
+	SessionCookie = "shiki_session"
+	LoginCookie   = "shiki_login"
+
+	// SessionMaxAge is how long a login lasts.
+	SessionMaxAge = 24 * time.Hour
+
+	// LoginMaxAge is how long the user has to log in at the provider.
+	LoginMaxAge = 10 * time.Minute
+
+	contextKey = "oidc-session"
+
+	randomBytes = 32
+
+	providerTimeout = 10 * time.Second
+)
+
+var ErrInvalidCookie = errors.New("invalid cookie")
+
+// OIDCService logs voters in with an OpenID Connect provider using the
+// authorization code flow. It is disabled without an issuer.
+type OIDCService struct {
+	Config   Config
+	Provider *Provider
+
+	SessionSecret []byte
+}
+
+func NewOIDCService(i do.Injector) (*OIDCService, error) {
+	config := Config{
+		Issuer:       do.MustInvokeNamed[string](i, "oidc-issuer"),
+		ClientID:     do.MustInvokeNamed[string](i, "oidc-client-id"),
+		ClientSecret: do.MustInvokeNamed[string](i, "oidc-client-secret"),
+		RedirectURL:  do.MustInvokeNamed[string](i, "oidc-redirect-url"),
+	}
+
+	signatureSecret := do.MustInvokeNamed[string](i, "signature-secret")
+
+	result := &OIDCService{
+		Config: config,
+	}
+
+	if config.Issuer == "" {
+		return result, nil
+	}
+
+	result.Provider = NewProvider(config.Issuer, &http.Client{Timeout: providerTimeout})
+	result.SessionSecret = DeriveSessionSecret(signatureSecret)
+
+	echoService, err := do.Invoke[*common.EchoService](i)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create echo service: %w", err)
+	}
+
+	echoService.Register(func(e *echo.Echo) {
+		e.Use(result.LoadSession())
+
+		result.Register(e.Group("/auth"))
+	})
+
+	return result, nil
+}
+
+// Register adds the login routes to a group.
+func (s *OIDCService) Register(authGroup *echo.Group) {
+	authGroup.GET("/login", s.GetLogin)
+	authGroup.GET("/callback", s.GetCallback)
+	authGroup.GET("/me", s.GetMe)
+	authGroup.POST("/logout", s.PostLogout)
+}
+
+// DeriveSessionSecret derives the key session cookies are signed with, so
+// the signature secret isn't used for two purposes.
+func DeriveSessionSecret(signatureSecret string) []byte {
+	return common.DeriveKey(signatureSecret, "oidc-session")
+}
+
+func randomString() (string, error) {
+	b := make([]byte, randomBytes)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *OIDCService) sign(payload string) string {
+	h := hmac.New(sha256.New, s.SessionSecret)
+	h.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// encodeCookie signs a value for a cookie. The value is readable by the
+// client but can't be changed.
+func (s *OIDCService) encodeCookie(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cookie: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + s.sign(payload), nil
+}
+
+func (s *OIDCService) decodeCookie(value string, v any) error {
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return ErrInvalidCookie
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCookie, err)
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCookie, err)
+	}
+
+	return nil
+}
+
+func (s *OIDCService) cookie(name, value, path string, maxAge time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(s.Config.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// UserFromContext returns the session of a logged in user.
+func UserFromContext(c echo.Context) (Session, bool) {
+	session, ok := c.Get(contextKey).(Session)
+
+	return session, ok
+}
+
+// LoadSession reads the session cookie and stores the session in the context.
+// Invalid or expired sessions are ignored.
+func (s *OIDCService) LoadSession() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cookie, err := c.Cookie(SessionCookie)
+			if err != nil {
+				return next(c)
+			}
+
+			var session Session
+
+			err = s.decodeCookie(cookie.Value, &session)
+			if err == nil && time.Now().Unix() < session.Expiry {
+				c.Set(contextKey, session)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (s *OIDCService) GetLogin(c echo.Context) error {
+	metadata, err := s.Provider.Metadata(c.Request().Context())
+	if err != nil {
+		log.Printf("failed to discover OIDC provider: %v", err)
+
+		return echo.NewHTTPError(http.StatusBadGateway, "identity provider unavailable")
+	}
+
+	state := login{Expiry: time.Now().Add(LoginMaxAge).Unix()}
+
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		*value, err = randomString()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to start login")
+		}
+	}
+
+	value, err := s.encodeCookie(state)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to start login")
+	}
+
+	c.SetCookie(s.cookie(LoginCookie, value, "/auth", LoginMaxAge))
+
+	challenge := sha256.Sum256([]byte(state.Verifier))
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.Config.ClientID},
+		"redirect_uri":          {s.Config.RedirectURL},
+		"scope":                 {"openid profile email"},
+		"state":                 {state.State},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	//nolint:wrapcheck
+	return c.Redirect(http.StatusFound, metadata.AuthorizationEndpoint+"?"+query.Encode())
+}
+
+func (s *OIDCService) GetCallback(c echo.Context) error {
+	if providerError := c.QueryParam("error"); providerError != "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "login failed: "+providerError)
+	}
+
+	cookie, err := c.Cookie(LoginCookie)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "no login in progress")
+	}
+
+	var state login
+
+	err = s.decodeCookie(cookie.Value, &state)
+	if err != nil || time.Now().Unix() >= state.Expiry {
+		return echo.NewHTTPError(http.StatusBadRequest, "login expired")
+	}
+
+	if !hmac.Equal([]byte(c.QueryParam("state")), []byte(state.State)) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid login state")
+	}
+
+	ctx := c.Request().Context()
+
+	tokens, err := s.Provider.Exchange(ctx, s.Config, c.QueryParam("code"), state.Verifier)
+	if err != nil {
+		log.Printf("failed to exchange authorization code: %v", err)
+
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to exchange authorization code")
+	}
+
+	claims, err := s.Provider.VerifyIDToken(ctx, tokens.IDToken, s.Config.ClientID, state.Nonce)
+	if err != nil {
+		log.Printf("failed to verify ID token: %v", err)
+
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid ID token")
+	}
+
+	session := Session{
+		UserID: claims.Subject,
+		Name:   claims.Name,
+		Email:  claims.Email,
+		Expiry: time.Now().Add(SessionMaxAge).Unix(),
+	}
+
+	value, err := s.encodeCookie(session)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create session")
+	}
+
+	c.SetCookie(s.cookie(LoginCookie, "", "/auth", -time.Second))
+	c.SetCookie(s.cookie(SessionCookie, value, "/", SessionMaxAge))
+
+	//nolint:wrapcheck
+	return c.Redirect(http.StatusFound, "/")
+}
+
+func (s *OIDCService) GetMe(c echo.Context) error {
+	session, ok := UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "not logged in")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, session, "  ")
+}
+
+func (s *OIDCService) PostLogout(c echo.Context) error {
+	c.SetCookie(s.cookie(SessionCookie, "", "/", -time.Second))
+
+	//nolint:wrapcheck
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,187 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	oidc "github.com/vreid/shiki/internal/pkg/oidc"
+)
+
+const testRedirectURL = "http://shiki.test/auth/callback"
+
+func newTestService(provider *fakeProvider) (*oidc.OIDCService, *echo.Echo) {
+	service := &oidc.OIDCService{
+		Config: oidc.Config{
+			Issuer:       provider.issuer(),
+			ClientID:     testClientID,
+			ClientSecret: testClientSecret,
+			RedirectURL:  testRedirectURL,
+		},
+		Provider:      oidc.NewProvider(provider.issuer(), provider.server.Client()),
+		SessionSecret: oidc.DeriveSessionSecret("secret"),
+	}
+
+	e := echo.New()
+	e.Use(service.LoadSession())
+	service.Register(e.Group("/auth"))
+
+	return service, e
+}
+
+func serve(e *echo.Echo, target string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// login runs the authorization code flow and returns the cookies the
+// callback set.
+func login(t *testing.T, provider *fakeProvider, e *echo.Echo) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := serve(e, "/auth/login", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	client := provider.server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, rec.Header().Get(echo.HeaderLocation), nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	return serve(e, callback.RequestURI(), rec.Result().Cookies())
+}
+
+func sessionCookie(rec *httptest.ResponseRecorder) []*http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == oidc.SessionCookie {
+			return []*http.Cookie{cookie}
+		}
+	}
+
+	return nil
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	provider := newFakeProvider(t)
+	_, e := newTestService(provider)
+
+	rec := login(t, provider, e)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	cookies := sessionCookie(rec)
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+
+	rec = serve(e, "/auth/me", cookies)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var session oidc.Session
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &session))
+	assert.Equal(t, "user-1", session.UserID)
+	assert.Equal(t, "Test User", session.Name)
+
+	cookies[0].Value = "x" + cookies[0].Value
+
+	rec = serve(e, "/auth/me", cookies)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestLoginRejectsInvalidState(t *testing.T) {
+	t.Parallel()
+
+	provider := newFakeProvider(t)
+	_, e := newTestService(provider)
+
+	rec := serve(e, "/auth/login", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	rec = serve(e, "/auth/callback?code=x&state=forged", rec.Result().Cookies())
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(e, "/auth/callback?code=x&state=forged", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestLoginRejectsInvalidIDToken(t *testing.T) {
+	t.Parallel()
+
+	provider := newFakeProvider(t)
+	provider.claims["aud"] = "someone-else"
+
+	_, e := newTestService(provider)
+
+	rec := login(t, provider, e)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, sessionCookie(rec))
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	t.Parallel()
+
+	provider := newFakeProvider(t)
+	verifier := oidc.NewProvider(provider.issuer(), provider.server.Client())
+
+	ctx := context.Background()
+
+	claims, err := verifier.VerifyIDToken(ctx, provider.idToken("user-2", "n"), testClientID, "n")
+	require.NoError(t, err)
+	assert.Equal(t, "user-2", claims.Subject)
+
+	_, err = verifier.VerifyIDToken(ctx, provider.idToken("user-2", "n"), testClientID, "other")
+	require.ErrorIs(t, err, oidc.ErrNonceMismatch)
+
+	_, err = verifier.VerifyIDToken(ctx, provider.idToken("user-2", "n"), "other-client", "n")
+	require.ErrorIs(t, err, oidc.ErrAudienceMismatch)
+
+	valid := provider.idToken("user-2", "n")
+
+	_, err = verifier.VerifyIDToken(ctx, valid[:len(valid)-4]+"AAAA", testClientID, "n")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+	unsigned := provider.sign(map[string]string{"alg": "none"}, map[string]any{"sub": "user-2"})
+
+	_, err = verifier.VerifyIDToken(ctx, unsigned, testClientID, "n")
+	require.ErrorIs(t, err, oidc.ErrUnsupportedAlgorithm)
+
+	unknownKey := provider.sign(map[string]string{"alg": "RS256", "kid": "key-2"}, map[string]any{"sub": "user-2"})
+
+	_, err = verifier.VerifyIDToken(ctx, unknownKey, testClientID, "n")
+	require.ErrorIs(t, err, oidc.ErrUnknownKey)
+
+	provider.claims["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	_, err = verifier.VerifyIDToken(ctx, provider.idToken("user-2", "n"), testClientID, "n")
+	require.ErrorIs(t, err, oidc.ErrTokenExpired)
+
+	provider.claims["exp"] = time.Now().Add(time.Hour).Unix()
+	provider.claims["iss"] = "https://evil.example.com"
+
+	_, err = verifier.VerifyIDToken(ctx, provider.idToken("user-2", "n"), testClientID, "n")
+	require.ErrorIs(t, err, oidc.ErrIssuerMismatch)
+}
@@ -0,0 +1,197 @@
+package oidc_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClientID     = "shiki"
+	testClientSecret = "client-secret"
+	testKeyID        = "key-1"
+)
+
+type authorization struct {
+	subject   string
+	nonce     string
+	challenge string
+}
+
+// fakeProvider is an in-process OpenID Connect provider. It logs in whoever
+// it is told to and hands out ID tokens signed with its RSA key.
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu      sync.Mutex
+	subject string
+	codes   map[string]authorization
+
+	// claims are added to or override the claims of issued ID tokens.
+	claims map[string]any
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &fakeProvider{
+		t:       t,
+		key:     key,
+		subject: "user-1",
+		codes:   map[string]authorization{},
+		claims:  map[string]any{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func (p *fakeProvider) issuer() string {
+	return p.server.URL
+}
+
+func (p *fakeProvider) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (p *fakeProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	p.writeJSON(w, map[string]string{
+		"issuer":                 p.issuer(),
+		"authorization_endpoint": p.issuer() + "/authorize",
+		"token_endpoint":         p.issuer() + "/token",
+		"jwks_uri":               p.issuer() + "/jwks",
+	})
+}
+
+func (p *fakeProvider) jwks(w http.ResponseWriter, _ *http.Request) {
+	p.writeJSON(w, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *fakeProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("client_id") != testClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+
+		return
+	}
+
+	p.mu.Lock()
+	code := "code-" + query.Get("state")
+	p.codes[code] = authorization{
+		subject:   p.subject,
+		nonce:     query.Get("nonce"),
+		challenge: query.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	require.NoError(p.t, err)
+
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || clientSecret != testClientSecret {
+		http.Error(w, "invalid client", http.StatusUnauthorized)
+
+		return
+	}
+
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != auth.challenge {
+		http.Error(w, "invalid grant", http.StatusBadRequest)
+
+		return
+	}
+
+	p.writeJSON(w, map[string]string{
+		"id_token":     p.idToken(auth.subject, auth.nonce),
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+	})
+}
+
+// idToken signs an ID token for a subject.
+func (p *fakeProvider) idToken(subject, nonce string) string {
+	now := time.Now()
+
+	claims := map[string]any{
+		"iss":   p.issuer(),
+		"sub":   subject,
+		"aud":   testClientID,
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"nonce": nonce,
+		"name":  "Test User",
+	}
+
+	p.mu.Lock()
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	p.mu.Unlock()
+
+	return p.sign(map[string]string{"alg": "RS256", "kid": testKeyID, "typ": "JWT"}, claims)
+}
+
+func (p *fakeProvider) sign(header map[string]string, claims map[string]any) string {
+	encode := func(v any) string {
+		b, err := json.Marshal(v)
+		require.NoError(p.t, err)
+
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	input := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(input))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	require.NoError(p.t, err)
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
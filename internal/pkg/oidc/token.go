@@ -0,0 +1,316 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Leeway is the clock skew allowed when checking token timestamps.
+	Leeway = time.Minute
+
+	// keysRefreshInterval limits how often the JWKS is fetched again for
+	// tokens signed with an unknown key.
+	keysRefreshInterval = time.Minute
+)
+
+var (
+	ErrInvalidIDToken       = errors.New("invalid ID token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrUnknownKey           = errors.New("unknown signing key")
+	ErrIssuerMismatch       = errors.New("issuer mismatch")
+	ErrAudienceMismatch     = errors.New("audience mismatch")
+	ErrTokenExpired         = errors.New("token expired")
+	ErrNonceMismatch        = errors.New("nonce mismatch")
+	ErrTokenRequestFailed   = errors.New("token request failed")
+)
+
+// Provider talks to an OpenID Connect provider. Its metadata and signing
+// keys are fetched on first use and cached.
+type Provider struct {
+	Issuer string
+	Client *http.Client
+
+	mu          sync.Mutex
+	metadata    *ProviderMetadata
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+func NewProvider(issuer string, client *http.Client) *Provider {
+	return &Provider{
+		Issuer: strings.TrimSuffix(issuer, "/"),
+		Client: client,
+	}
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", u, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: %s", u, resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", u, err)
+	}
+
+	return nil
+}
+
+// Metadata returns the discovery document of the provider.
+func (p *Provider) Metadata(ctx context.Context) (ProviderMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return *p.metadata, nil
+	}
+
+	var result ProviderMetadata
+
+	err := p.getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", &result)
+	if err != nil {
+		return ProviderMetadata{}, err
+	}
+
+	if result.Issuer != p.Issuer {
+		return ProviderMetadata{}, fmt.Errorf("%w: discovered %q", ErrIssuerMismatch, result.Issuer)
+	}
+
+	p.metadata = &result
+
+	return result, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key parameter: %w", err)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) error {
+	var set jwks
+
+	err := p.getJSON(ctx, jwksURI, &set)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return err
+		}
+
+		e, err := decodeBigInt(key.E)
+		if err != nil {
+			return err
+		}
+
+		keys[key.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	}
+
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	return nil
+}
+
+// key returns the signing key with the given ID. The JWKS is fetched again
+// when the key is unknown, so keys can be rotated by the provider.
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if p.keys != nil && time.Since(p.keysFetched) < keysRefreshInterval {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	err = p.fetchKeys(ctx, metadata.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	return key, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	return nil
+}
+
+// VerifyIDToken checks the signature of an ID token against the provider's
+// JWKS and validates its issuer, audience, expiry and nonce.
+//
+//nolint:cyclop // Every claim needs its own check
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, clientID, nonce string) (Claims, error) {
+	segments := strings.Split(raw, ".")
+	if len(segments) != 3 {
+		return Claims{}, fmt.Errorf("%w: expected 3 segments, got %d", ErrInvalidIDToken, len(segments))
+	}
+
+	var header tokenHeader
+
+	err := decodeSegment(segments[0], &header)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if header.Alg != "RS256" {
+		return Claims{}, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, header.Alg)
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	digest := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	var claims Claims
+
+	err = decodeSegment(segments[1], &claims)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	now := time.Now()
+
+	switch {
+	case claims.Issuer != p.Issuer:
+		return Claims{}, fmt.Errorf("%w: %q", ErrIssuerMismatch, claims.Issuer)
+	case !slices.Contains(claims.Audience, clientID):
+		return Claims{}, fmt.Errorf("%w: %v", ErrAudienceMismatch, []string(claims.Audience))
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != clientID:
+		return Claims{}, fmt.Errorf("%w: authorized party %q", ErrAudienceMismatch, claims.AuthorizedParty)
+	case now.After(time.Unix(claims.Expiry, 0).Add(Leeway)):
+		return Claims{}, ErrTokenExpired
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(Leeway)):
+		return Claims{}, fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	case claims.Subject == "":
+		return Claims{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	case claims.Nonce != nonce:
+		return Claims{}, ErrNonceMismatch
+	}
+
+	return claims, nil
+}
+
+// Exchange trades an authorization code for the tokens of the user.
+func (p *Provider) Exchange(ctx context.Context, config Config, code, verifier string) (TokenResponse, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {config.RedirectURL},
+		"client_id":     {config.ClientID},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("failed to request token: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return TokenResponse{}, fmt.Errorf("%w: %s", ErrTokenRequestFailed, resp.Status)
+	}
+
+	var result TokenResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if result.IDToken == "" {
+		return TokenResponse{}, fmt.Errorf("%w: no ID token", ErrTokenRequestFailed)
+	}
+
+	return result, nil
+}
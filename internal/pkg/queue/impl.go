@@ -0,0 +1,250 @@
+package queue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	ErrQueueFull      = errors.New("queue is full")
+	ErrBucketNotFound = errors.New("queue bucket doesn't exist")
+)
+
+// Entry is a queued value together with the ID needed to acknowledge it. Err
+// is set instead of Value when the stored entry cannot be decoded.
+type Entry[T any] struct {
+	ID    uint64
+	Value T
+	Err   error
+}
+
+// Queue is a durable FIFO queue stored in a bbolt bucket. Entries stay in the
+// bucket until they are acknowledged, so delivery is at-least-once.
+type Queue[T any] struct {
+	db *bolt.DB
+
+	bucket     []byte
+	deadBucket []byte
+
+	capacity int64
+	length   atomic.Int64
+
+	notify chan struct{}
+}
+
+func NewQueue[T any](db *bolt.DB, bucket string, capacity int) (*Queue[T], error) {
+	result := &Queue[T]{
+		db: db,
+
+		bucket:     []byte(bucket),
+		deadBucket: []byte(bucket + ":dead"),
+
+		capacity: int64(capacity),
+
+		notify: make(chan struct{}, 1),
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(result.bucket)
+		if err != nil {
+			return fmt.Errorf("failed to create %s bucket: %w", bucket, err)
+		}
+
+		_, err = tx.CreateBucketIfNotExists(result.deadBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create %s bucket: %w", result.deadBucket, err)
+		}
+
+		result.length.Store(int64(b.Stats().KeyN))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize queue: %w", err)
+	}
+
+	return result, nil
+}
+
+func (q *Queue[T]) Len() int {
+	return int(q.length.Load())
+}
+
+// Notify returns a channel that receives a value whenever new entries have
+// been enqueued.
+func (q *Queue[T]) Notify() <-chan struct{} {
+	return q.notify
+}
+
+// Enqueue durably stores a value. It returns ErrQueueFull instead of blocking
+// when the queue holds capacity entries.
+func (q *Queue[T]) Enqueue(value T) error {
+	if q.length.Add(1) > q.capacity {
+		q.length.Add(-1)
+
+		return ErrQueueFull
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		q.length.Add(-1)
+
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+
+	err = q.db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(q.bucket)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return fmt.Errorf("failed to generate entry ID: %w", err)
+		}
+
+		return b.Put(itob(id), data)
+	})
+	if err != nil {
+		q.length.Add(-1)
+
+		return fmt.Errorf("failed to enqueue entry: %w", err)
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Peek returns up to n of the oldest entries without removing them.
+func (q *Queue[T]) Peek(n int) ([]Entry[T], error) {
+	result := make([]Entry[T], 0, n)
+
+	err := q.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(q.bucket)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil && len(result) < n; k, v = c.Next() {
+			entry := Entry[T]{ID: binary.BigEndian.Uint64(k)}
+
+			err := json.Unmarshal(v, &entry.Value)
+			if err != nil {
+				entry.Err = fmt.Errorf("failed to unmarshal entry: %w", err)
+			}
+
+			result = append(result, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to peek entries: %w", err)
+	}
+
+	return result, nil
+}
+
+// Ack removes processed entries from the queue.
+func (q *Queue[T]) Ack(ids ...uint64) error {
+	return q.remove(ids, false)
+}
+
+// DeadLetter moves entries that can never be processed out of the queue.
+func (q *Queue[T]) DeadLetter(ids ...uint64) error {
+	return q.remove(ids, true)
+}
+
+// AckTx removes processed entries within an existing read-write
+// transaction, so they are acknowledged together with what processing them
+// wrote.
+func (q *Queue[T]) AckTx(tx *bolt.Tx, ids ...uint64) error {
+	return q.removeTx(tx, ids, false)
+}
+
+// DeadLetterTx moves entries out of the queue within an existing read-write
+// transaction.
+func (q *Queue[T]) DeadLetterTx(tx *bolt.Tx, ids ...uint64) error {
+	return q.removeTx(tx, ids, true)
+}
+
+func (q *Queue[T]) remove(ids []uint64, deadLetter bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		return q.removeTx(tx, ids, deadLetter)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove entries: %w", err)
+	}
+
+	return nil
+}
+
+// removeTx deletes entries from the queue bucket. The length only shrinks
+// once the transaction is committed.
+func (q *Queue[T]) removeTx(tx *bolt.Tx, ids []uint64, deadLetter bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	b := tx.Bucket(q.bucket)
+	if b == nil {
+		return ErrBucketNotFound
+	}
+
+	dead := tx.Bucket(q.deadBucket)
+	if dead == nil {
+		return ErrBucketNotFound
+	}
+
+	removed := int64(0)
+
+	for _, id := range ids {
+		key := itob(id)
+
+		value := b.Get(key)
+		if value == nil {
+			continue
+		}
+
+		if deadLetter {
+			err := dead.Put(key, value)
+			if err != nil {
+				return fmt.Errorf("failed to put dead letter: %w", err)
+			}
+		}
+
+		err := b.Delete(key)
+		if err != nil {
+			return fmt.Errorf("failed to delete entry: %w", err)
+		}
+
+		removed++
+	}
+
+	tx.OnCommit(func() {
+		q.length.Add(-removed)
+	})
+
+	return nil
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+
+	return b
+}
@@ -0,0 +1,111 @@
+package queue_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	queue "github.com/vreid/shiki/internal/pkg/queue"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenDatabase(t)
+
+	q, err := queue.NewQueue[string](db, "queue:test", 2)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Enqueue("a"))
+	require.NoError(t, q.Enqueue("b"))
+	require.ErrorIs(t, q.Enqueue("c"), queue.ErrQueueFull)
+	assert.Equal(t, 2, q.Len())
+
+	entries, err := q.Peek(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a", entries[0].Value)
+	assert.Equal(t, "b", entries[1].Value)
+
+	require.NoError(t, q.Ack(entries[0].ID))
+	require.NoError(t, q.DeadLetter(entries[1].ID))
+	assert.Equal(t, 0, q.Len())
+
+	entries, err = q.Peek(10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestQueueAckTx(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenDatabase(t)
+
+	q, err := queue.NewQueue[string](db, "queue:test", 10)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Enqueue("a"))
+	require.NoError(t, q.Enqueue("b"))
+
+	entries, err := q.Peek(10)
+	require.NoError(t, err)
+
+	// Entries acknowledged in a rolled back transaction stay queued.
+	require.ErrorIs(t, db.Update(func(tx *bolt.Tx) error {
+		require.NoError(t, q.AckTx(tx, entries[0].ID))
+
+		return queue.ErrQueueFull
+	}), queue.ErrQueueFull)
+	assert.Equal(t, 2, q.Len())
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		require.NoError(t, q.AckTx(tx, entries[0].ID))
+
+		return q.DeadLetterTx(tx, entries[1].ID)
+	}))
+	assert.Equal(t, 0, q.Len())
+
+	entries, err = q.Peek(10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestQueueDurable(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "shiki-test.db")
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+
+	q, err := queue.NewQueue[string](db, "queue:test", 10)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Enqueue("a"))
+	require.NoError(t, q.Enqueue("b"))
+
+	entries, err := q.Peek(1)
+	require.NoError(t, err)
+	require.NoError(t, q.Ack(entries[0].ID))
+	require.NoError(t, db.Close())
+
+	db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	q, err = queue.NewQueue[string](db, "queue:test", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, q.Len())
+
+	entries, err = q.Peek(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "b", entries[0].Value)
+}
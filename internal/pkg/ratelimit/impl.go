@@ -0,0 +1,287 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/voter"
+	"golang.org/x/time/rate"
+)
+
+// sweepInterval is how often idle clients are forgotten.
+const sweepInterval = time.Minute
+
+var (
+	ErrInvalidLimit = errors.New("limits must be given as <requests>/<s|m|h>[,burst=<n>][,key=<ip|voter|key>]")
+	ErrUnknownKey   = errors.New("unknown rate limit key")
+)
+
+var periods = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseLimit parses a limit like "60/m,burst=10,key=voter". The burst
+// defaults to the number of requests and the key to the client IP. An empty
+// value disables the limit.
+//
+//nolint:cyclop // Parsing every option requires this complexity
+func ParseLimit(value string) (Limit, error) {
+	if strings.TrimSpace(value) == "" {
+		return Limit{}, nil
+	}
+
+	parts := strings.Split(value, ",")
+
+	requests, unit, ok := strings.Cut(strings.TrimSpace(parts[0]), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+	}
+
+	count, err := strconv.Atoi(requests)
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+	}
+
+	period, ok := periods[unit]
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+	}
+
+	result := Limit{Requests: count, Period: period, Burst: count, Key: KeyIP}
+
+	for _, option := range parts[1:] {
+		name, optionValue, _ := strings.Cut(strings.TrimSpace(option), "=")
+
+		switch name {
+		case "burst":
+			result.Burst, err = strconv.Atoi(optionValue)
+			if err != nil || result.Burst <= 0 {
+				return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+			}
+		case "key":
+			switch key := KeyType(optionValue); key {
+			case KeyIP, KeyVoter, KeyAPI:
+				result.Key = key
+			default:
+				return Limit{}, fmt.Errorf("%w: %s", ErrUnknownKey, optionValue)
+			}
+		default:
+			return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+		}
+	}
+
+	return result, nil
+}
+
+func (l *Limit) UnmarshalText(text []byte) error {
+	limit, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+
+	*l = limit
+
+	return nil
+}
+
+// Enabled reports whether the limit limits anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0
+}
+
+// LoadConfig reads limits from a JSON config file.
+func LoadConfig(path string) (Config, error) {
+	//nolint:gosec // Path is given by the operator
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read rate limit config: %w", err)
+	}
+
+	var result Config
+
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse rate limit config: %w", err)
+	}
+
+	return result, nil
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter keeps a token bucket per client.
+type Limiter struct {
+	Limit Limit
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		Limit:     limit,
+		clients:   map[string]*client{},
+		lastSweep: time.Now(),
+	}
+}
+
+// reserve takes a token from the bucket of a client. It returns how long the
+// client has to wait if there is none, and the tokens left.
+func (l *Limiter) reserve(key string, now time.Time) (time.Duration, float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	current, ok := l.clients[key]
+	if !ok {
+		every := rate.Every(l.Limit.Period / time.Duration(l.Limit.Requests))
+		current = &client{limiter: rate.NewLimiter(every, l.Limit.Burst)}
+		l.clients[key] = current
+	}
+
+	current.lastSeen = now
+
+	reservation := current.limiter.ReserveN(now, 1)
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+
+	return delay, current.limiter.TokensAt(now)
+}
+
+// sweep forgets clients whose bucket has filled up again, they are no
+// different from new ones.
+func (l *Limiter) sweep(now time.Time) {
+	for key, current := range l.clients {
+		if current.limiter.TokensAt(now) >= float64(l.Limit.Burst) {
+			delete(l.clients, key)
+		}
+	}
+
+	l.lastSweep = now
+}
+
+// Key returns who a request is counted against. Anonymous voters get a new
+// ID with every cookie-less request, so only logged in users and API keys are
+// counted by voter ID; everyone else is counted against their IP.
+func (l *Limiter) Key(c echo.Context) string {
+	switch l.Limit.Key {
+	case KeyVoter:
+		if voterID := voter.FromContext(c); voter.Authenticated(voterID) {
+			return "voter:" + voterID
+		}
+	case KeyAPI:
+		if key, ok := auth.KeyFromContext(c); ok {
+			return "key:" + key.ID
+		}
+	case KeyIP:
+	}
+
+	return "ip:" + c.RealIP()
+}
+
+// Middleware rejects requests over the limit with 429 and tells every client
+// about its limit in the RateLimit-* headers.
+func (l *Limiter) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if !l.Limit.Enabled() {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			delay, tokens := l.reserve(l.Key(c), time.Now())
+
+			refill := l.Limit.Period / time.Duration(l.Limit.Requests)
+			reset := time.Duration((float64(l.Limit.Burst) - tokens) * float64(refill))
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(l.Limit.Burst))
+			header.Set("RateLimit-Remaining", strconv.Itoa(max(int(tokens), 0)))
+			header.Set("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+
+			if delay > 0 {
+				header.Set(echo.HeaderRetryAfter, strconv.Itoa(seconds(delay)))
+
+				return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// seconds rounds a duration up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// RateLimitService holds the limiters of the rate limited endpoints.
+type RateLimitService struct {
+	MatchUp *Limiter
+	Outcome *Limiter
+	Upload  *Limiter
+}
+
+func NewRateLimitService(i do.Injector) (*RateLimitService, error) {
+	config := Config{}
+
+	if path := do.MustInvokeNamed[string](i, "rate-limit-config"); path != "" {
+		var err error
+
+		config, err = LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Limits given as flags override the config file.
+	overrides := map[string]*Limit{
+		"rate-limit-match-up": &config.MatchUp,
+		"rate-limit-outcome":  &config.Outcome,
+		"rate-limit-upload":   &config.Upload,
+	}
+
+	for name, limit := range overrides {
+		value := do.MustInvokeNamed[string](i, name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", name, err)
+		}
+
+		*limit = parsed
+	}
+
+	result := &RateLimitService{
+		MatchUp: NewLimiter(config.MatchUp),
+		Outcome: NewLimiter(config.Outcome),
+		Upload:  NewLimiter(config.Upload),
+	}
+
+	return result, nil
+}
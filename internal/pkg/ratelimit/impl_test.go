@@ -0,0 +1,181 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	ratelimit "github.com/vreid/shiki/internal/pkg/ratelimit"
+	"github.com/vreid/shiki/internal/pkg/voter"
+)
+
+func TestParseLimit(t *testing.T) {
+	t.Parallel()
+
+	limit, err := ratelimit.ParseLimit("60/m,burst=10,key=voter")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 60, Period: time.Minute, Burst: 10, Key: ratelimit.KeyVoter}, limit)
+
+	limit, err = ratelimit.ParseLimit("5/s")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 5, Period: time.Second, Burst: 5, Key: ratelimit.KeyIP}, limit)
+
+	limit, err = ratelimit.ParseLimit("")
+	require.NoError(t, err)
+	assert.False(t, limit.Enabled())
+
+	for _, value := range []string{"5", "0/s", "5/d", "5/s,burst=0", "5/s,speed=1"} {
+		_, err = ratelimit.ParseLimit(value)
+		require.ErrorIs(t, err, ratelimit.ErrInvalidLimit, value)
+	}
+
+	_, err = ratelimit.ParseLimit("5/s,key=cookie")
+	require.ErrorIs(t, err, ratelimit.ErrUnknownKey)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"match_up": "2/s,burst=4", "upload": "10/h,key=key"}`), 0600))
+
+	config, err := ratelimit.LoadConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, config.MatchUp.Burst)
+	assert.False(t, config.Outcome.Enabled())
+	assert.Equal(t, ratelimit.KeyAPI, config.Upload.Key)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 2, Key: ratelimit.KeyIP})
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limiter.Middleware())
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve("192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = serve("192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "7200", rec.Header().Get("RateLimit-Reset"))
+
+	rec = serve("192.0.2.1:1234")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3600", rec.Header().Get(echo.HeaderRetryAfter))
+
+	rec = serve("192.0.2.2:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMiddlewareIgnoresForwardedHeaders(t *testing.T) {
+	t.Parallel()
+
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 1, Key: ratelimit.KeyIP})
+
+	ipExtractor, err := common.IPExtractor(nil)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.IPExtractor = ipExtractor
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limiter.Middleware())
+
+	serve := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve("198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("198.51.100.2"))
+}
+
+func TestTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	ipExtractor, err := common.IPExtractor([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:1234"
+	req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.1")
+	assert.Equal(t, "198.51.100.1", ipExtractor(req))
+
+	req.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "192.0.2.1", ipExtractor(req))
+
+	_, err = common.IPExtractor([]string{"proxy"})
+	require.Error(t, err)
+}
+
+func TestVoterKey(t *testing.T) {
+	t.Parallel()
+
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 1, Key: ratelimit.KeyVoter})
+
+	authService := &auth.AuthService{AdminToken: "admin"}
+	voterService := &voter.VoterService{CookieSecret: common.DeriveKey("secret", "voter-cookie")}
+
+	e := echo.New()
+	e.Use(authService.Authenticate(), voterService.Identify())
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limiter.Middleware())
+
+	serve := func(remoteAddr string, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	// Cookie-less requests get a fresh anonymous ID each time and fall back
+	// to their IP.
+	require.Equal(t, http.StatusOK, serve("192.0.2.1:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serve("192.0.2.1:1234", ""))
+	assert.Equal(t, http.StatusOK, serve("192.0.2.2:1234", ""))
+
+	// Authenticated voters are counted by ID wherever they come from.
+	require.Equal(t, http.StatusOK, serve("192.0.2.1:1234", "admin"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("192.0.2.3:1234", "admin"))
+}
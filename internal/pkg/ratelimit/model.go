@@ -0,0 +1,29 @@
+package ratelimit
+
+import "time"
+
+// KeyType is what requests are grouped by when counting them against a limit.
+type KeyType string
+
+const (
+	KeyIP    KeyType = "ip"
+	KeyVoter KeyType = "voter"
+	KeyAPI   KeyType = "key"
+)
+
+// Limit is a token bucket that refills Requests tokens every Period and
+// holds at most Burst tokens. The zero Limit doesn't limit anything.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+	Key      KeyType
+}
+
+// Config holds the limits of the rate limited endpoints. In a config file
+// every limit is given in the same format as on the command line.
+type Config struct {
+	MatchUp Limit `json:"match_up"`
+	Outcome Limit `json:"outcome"`
+	Upload  Limit `json:"upload"`
+}
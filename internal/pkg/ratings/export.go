@@ -0,0 +1,69 @@
+package ratings
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+// WriteRatings writes ratings as json, csv or ndjson.
+func WriteRatings(w io.Writer, format string, ratings []Rating) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		//nolint:wrapcheck
+		return encoder.Encode(ratings)
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+
+		for _, rating := range ratings {
+			err := encoder.Encode(rating)
+			if err != nil {
+				return fmt.Errorf("failed to encode rating: %w", err)
+			}
+		}
+
+		return nil
+	case "csv":
+		return writeRatingsCSV(w, ratings)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+func writeRatingsCSV(w io.Writer, ratings []Rating) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"rank", "asset_id", "rating", "games", "wins", "losses", "ties", "percentile"})
+	if err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, rating := range ratings {
+		err := writer.Write([]string{
+			strconv.Itoa(rating.Rank),
+			rating.AssetID,
+			strconv.FormatFloat(rating.Rating, 'f', 2, 64),
+			strconv.FormatInt(rating.Games, 10),
+			strconv.FormatInt(rating.Wins, 10),
+			strconv.FormatInt(rating.Losses, 10),
+			strconv.FormatInt(rating.Ties, 10),
+			strconv.FormatFloat(rating.Percentile, 'f', 2, 64),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write csv record: %w", err)
+		}
+	}
+
+	writer.Flush()
+
+	//nolint:wrapcheck
+	return writer.Error()
+}
@@ -0,0 +1,33 @@
+package ratings_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ratings "github.com/vreid/shiki/internal/pkg/ratings"
+)
+
+func TestWriteRatings(t *testing.T) {
+	t.Parallel()
+
+	result := []ratings.Rating{
+		{AssetID: "a-1", Rating: 1510.0, Games: 3, Wins: 2, Losses: 1, Ties: 1, Rank: 1, Percentile: 100.0},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, ratings.WriteRatings(&buf, "csv", result))
+	assert.Equal(t, "rank,asset_id,rating,games,wins,losses,ties,percentile\n1,a-1,1510.00,3,2,1,1,100.00\n", buf.String())
+
+	buf.Reset()
+
+	require.NoError(t, ratings.WriteRatings(&buf, "ndjson", result))
+	assert.JSONEq(t,
+		`{"asset_id":"a-1","rating":1510,"deviation":0,"games":3,"wins":2,"losses":1,"ties":1,
+			"first_seen":0,"last_updated":0,"rank":1,"percentile":100}`,
+		buf.String())
+
+	require.ErrorIs(t, ratings.WriteRatings(&buf, "xml", result), ratings.ErrUnknownFormat)
+}
@@ -0,0 +1,538 @@
+package ratings
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/analysis"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 1000
+
+	DefaultReplicates = 200
+	MaxReplicates     = 1000
+
+	// MaxBootstraps is how many bootstraps run at once. Each one already
+	// keeps every CPU core busy.
+	MaxBootstraps = 2
+)
+
+var ErrAssetNotFound = errors.New("asset has no rating")
+
+type RatingsService struct {
+	DatabaseService *common.DatabaseService
+	ContestService  *contest.ContestService
+
+	// Bootstraps holds a token for every running bootstrap, nil doesn't
+	// limit them.
+	Bootstraps chan struct{}
+}
+
+func NewRatingsService(i do.Injector) (*RatingsService, error) {
+	databaseService := do.MustInvoke[*common.DatabaseService](i)
+	contestService := do.MustInvoke[*contest.ContestService](i)
+	authService := do.MustInvoke[*auth.AuthService](i)
+
+	result := &RatingsService{
+		DatabaseService: databaseService,
+		ContestService:  contestService,
+
+		Bootstraps: make(chan struct{}, MaxBootstraps),
+	}
+
+	echoService, err := do.Invoke[*common.EchoService](i)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create echo service: %w", err)
+	}
+
+	echoService.Register(func(e *echo.Echo) {
+		apiGroup := e.Group("/api")
+
+		// Bootstraps refit the ratings hundreds of times, so they need a key
+		// even when the ratings are public.
+		requireKey := authService.Require(auth.ScopeReadOnly)
+
+		result.register(apiGroup.Group("/ratings", authService.RequireReader()), requireKey)
+		result.register(apiGroup.Group("/contests/:contestID/ratings", authService.RequireReader()), requireKey)
+	})
+
+	return result, nil
+}
+
+func (s *RatingsService) register(ratingsGroup *echo.Group, requireKey echo.MiddlewareFunc) {
+	ratingsGroup.GET("", s.GetLeaderboard)
+	ratingsGroup.GET("/matrix", s.GetMatrix)
+	ratingsGroup.GET("/bootstrap", s.GetBootstrap, requireKey)
+	ratingsGroup.GET("/:assetID", s.GetRating)
+	ratingsGroup.GET("/:assetID/history", s.GetHistory)
+	ratingsGroup.GET("/:assetID/head-to-head", s.GetHeadToHead)
+}
+
+// LoadRatings reads every rated asset of a ratings namespace, sorted by
+// descending rating, with rank and percentile filled in.
+func LoadRatings(tx *bolt.Tx, namespace string) ([]Rating, error) {
+	result := []Rating{}
+
+	assets := tx.Bucket(common.NamespaceBucket(common.ScorerAssetsBucket, namespace))
+	if assets == nil {
+		return result, nil
+	}
+
+	err := assets.ForEach(func(k, v []byte) error {
+		record, err := common.DecodeAssetRecord(v)
+		if err != nil {
+			return fmt.Errorf("asset %s: %w", k, err)
+		}
+
+		result = append(result, newRating(string(k), record))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ratings: %w", err)
+	}
+
+	Rank(result)
+
+	return result, nil
+}
+
+// LoadRating reads the rating of a single asset. Its rank and percentile are
+// counted from the ratings of the other assets, without decoding their full
+// records or sorting them.
+func LoadRating(tx *bolt.Tx, namespace, assetID string) (Rating, error) {
+	assets := tx.Bucket(common.NamespaceBucket(common.ScorerAssetsBucket, namespace))
+	if assets == nil {
+		return Rating{}, ErrAssetNotFound
+	}
+
+	value := assets.Get([]byte(assetID))
+	if value == nil {
+		return Rating{}, ErrAssetNotFound
+	}
+
+	record, err := common.DecodeAssetRecord(value)
+	if err != nil {
+		return Rating{}, fmt.Errorf("asset %s: %w", assetID, err)
+	}
+
+	result := newRating(assetID, record)
+
+	ahead, total := 0, 0
+
+	err = assets.ForEach(func(k, v []byte) error {
+		rating, err := common.DecodeAssetRating(v)
+		if err != nil {
+			return fmt.Errorf("asset %s: %w", k, err)
+		}
+
+		total++
+
+		// Same order as Rank: by descending rating, then by asset ID.
+		if rating > result.Rating || (rating == result.Rating && string(k) < assetID) {
+			ahead++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Rating{}, fmt.Errorf("failed to read ratings: %w", err)
+	}
+
+	result.Rank = ahead + 1
+	result.Percentile = 100.0
+
+	if total > 1 {
+		result.Percentile = 100.0 * float64(total-1-ahead) / float64(total-1)
+	}
+
+	return result, nil
+}
+
+func newRating(assetID string, record common.AssetRecord) Rating {
+	return Rating{
+		AssetID:     assetID,
+		Rating:      record.Rating,
+		Deviation:   record.Deviation,
+		Games:       record.Games,
+		Wins:        record.Wins,
+		Losses:      record.Losses,
+		Ties:        record.Ties,
+		FirstSeen:   record.FirstSeen,
+		LastUpdated: record.LastUpdated,
+	}
+}
+
+// Rank sorts ratings by descending rating and assigns rank and percentile.
+func Rank(ratings []Rating) {
+	slices.SortStableFunc(ratings, func(a, b Rating) int {
+		if a.Rating != b.Rating {
+			if a.Rating > b.Rating {
+				return -1
+			}
+
+			return 1
+		}
+
+		if a.AssetID < b.AssetID {
+			return -1
+		}
+
+		return 1
+	})
+
+	for idx := range ratings {
+		ratings[idx].Rank = idx + 1
+		ratings[idx].Percentile = 100.0
+
+		if len(ratings) > 1 {
+			ratings[idx].Percentile = 100.0 * float64(len(ratings)-1-idx) / float64(len(ratings)-1)
+		}
+	}
+}
+
+// FilterMinGames returns the ratings of assets with at least minGames games,
+// ranked among themselves so that ranks and percentiles have no gaps.
+func FilterMinGames(ratings []Rating, minGames int64) []Rating {
+	if minGames <= 0 {
+		return ratings
+	}
+
+	result := slices.DeleteFunc(ratings, func(rating Rating) bool {
+		return rating.Games < minGames
+	})
+
+	Rank(result)
+
+	return result
+}
+
+// Downsample reduces a rating history. With a positive interval only the last
+// entry of every interval (in seconds) is kept; with a positive maxPoints at
+// most maxPoints evenly spaced entries are kept, always including the latest.
+func Downsample(history []scorer.HistoryEntry, interval int64, maxPoints int) []scorer.HistoryEntry {
+	result := history
+
+	if interval > 0 {
+		result = []scorer.HistoryEntry{}
+
+		for idx, entry := range history {
+			if idx+1 < len(history) && history[idx+1].Timestamp/interval == entry.Timestamp/interval {
+				continue
+			}
+
+			result = append(result, entry)
+		}
+	}
+
+	if maxPoints > 0 && len(result) > maxPoints {
+		sampled := make([]scorer.HistoryEntry, 0, maxPoints)
+
+		for idx := range maxPoints {
+			sampled = append(sampled, result[(idx+1)*len(result)/maxPoints-1])
+		}
+
+		result = sampled
+	}
+
+	return result
+}
+
+// namespace returns the ratings namespace of the contest and criterion of a
+// request.
+func (s *RatingsService) namespace(c echo.Context) (string, error) {
+	current, err := s.ContestService.FromContext(c)
+	if err != nil {
+		//nolint:wrapcheck
+		return "", err
+	}
+
+	criterion, err := contest.CriterionFromContext(c, current)
+	if err != nil {
+		//nolint:wrapcheck
+		return "", err
+	}
+
+	return contest.CriterionNamespace(current.Namespace(), criterion.ID), nil
+}
+
+func (s *RatingsService) loadRatings(namespace string) ([]Rating, error) {
+	var result []Rating
+
+	err := s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		result, err = LoadRatings(tx, namespace)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ratings: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *RatingsService) GetLeaderboard(c echo.Context) error {
+	limit, err := common.QueryInt(c, "limit", DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	offset, err := common.QueryInt(c, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	minGames, err := common.QueryInt(c, "min_games", 0)
+	if err != nil {
+		return err
+	}
+
+	limit = min(limit, MaxLimit)
+
+	namespace, err := s.namespace(c)
+	if err != nil {
+		return err
+	}
+
+	ratings, err := s.loadRatings(namespace)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load ratings")
+	}
+
+	ratings = FilterMinGames(ratings, int64(minGames))
+
+	start := min(offset, len(ratings))
+	end := min(start+limit, len(ratings))
+
+	leaderboard := Leaderboard{
+		Total:  len(ratings),
+		Limit:  limit,
+		Offset: offset,
+
+		Ratings: ratings[start:end],
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, leaderboard, "  ")
+}
+
+func (s *RatingsService) GetRating(c echo.Context) error {
+	assetID := c.Param("assetID")
+
+	namespace, err := s.namespace(c)
+	if err != nil {
+		return err
+	}
+
+	var rating Rating
+
+	err = s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		rating, err = LoadRating(tx, namespace, assetID)
+
+		return err
+	})
+	if errors.Is(err, ErrAssetNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, ErrAssetNotFound.Error())
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load rating")
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, rating, "  ")
+}
+
+func (s *RatingsService) GetHistory(c echo.Context) error {
+	assetID := c.Param("assetID")
+
+	interval, err := common.QueryInt(c, "interval", 0)
+	if err != nil {
+		return err
+	}
+
+	maxPoints, err := common.QueryInt(c, "max_points", 0)
+	if err != nil {
+		return err
+	}
+
+	namespace, err := s.namespace(c)
+	if err != nil {
+		return err
+	}
+
+	var history []scorer.HistoryEntry
+
+	err = s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		history, err = scorer.LoadHistory(tx, namespace, assetID)
+
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load history")
+	}
+
+	result := History{
+		AssetID: assetID,
+		History: Downsample(history, int64(interval), maxPoints),
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, result, "  ")
+}
+
+func (s *RatingsService) GetHeadToHead(c echo.Context) error {
+	assetID := c.Param("assetID")
+
+	namespace, err := s.namespace(c)
+	if err != nil {
+		return err
+	}
+
+	var records []scorer.PairRecord
+
+	err = s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		records, err = scorer.LoadPairRecords(tx, namespace, assetID)
+
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load head-to-head records")
+	}
+
+	result := HeadToHead{
+		AssetID: assetID,
+		Records: records,
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, result, "  ")
+}
+
+func (s *RatingsService) GetMatrix(c echo.Context) error {
+	top, err := common.QueryInt(c, "top", DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "csv" {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid format value")
+	}
+
+	namespace, err := s.namespace(c)
+	if err != nil {
+		return err
+	}
+
+	var matrix Matrix
+
+	err = s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		matrix, err = LoadMatrix(tx, namespace, min(top, MaxLimit))
+
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load head-to-head matrix")
+	}
+
+	if format == "csv" {
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+		c.Response().WriteHeader(http.StatusOK)
+
+		return WriteMatrixCSV(c.Response(), matrix)
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, matrix, "  ")
+}
+
+// GetBootstrap reports bootstrap confidence intervals of the ratings. It
+// needs a read-only key even when ratings are public, waits while
+// MaxBootstraps others run, and the computation is cancelled when the client
+// goes away.
+func (s *RatingsService) GetBootstrap(c echo.Context) error {
+	replicates, err := common.QueryInt(c, "replicates", DefaultReplicates)
+	if err != nil {
+		return err
+	}
+
+	if replicates == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid replicates value")
+	}
+
+	seed, err := common.QueryInt(c, "seed", 0)
+	if err != nil {
+		return err
+	}
+
+	top, err := common.QueryInt(c, "top", DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	namespace, err := s.namespace(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if s.Bootstraps != nil {
+		select {
+		case s.Bootstraps <- struct{}{}:
+			defer func() {
+				<-s.Bootstraps
+			}()
+		case <-ctx.Done():
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "bootstrap cancelled")
+		}
+	}
+
+	var games *analysis.Games
+
+	err = s.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		var err error
+
+		games, err = analysis.LoadGames(tx, namespace)
+
+		return err
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to load games")
+	}
+
+	//nolint:gosec // seed is non-negative
+	report, err := games.Bootstrap(ctx, min(replicates, MaxReplicates), uint64(seed))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "bootstrap cancelled")
+	}
+
+	if top > 0 && top < len(report.Intervals) {
+		report.Intervals = report.Intervals[:top]
+	}
+
+	//nolint:wrapcheck
+	return c.JSONPretty(http.StatusOK, report, "  ")
+}
@@ -0,0 +1,323 @@
+package ratings_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	ratings "github.com/vreid/shiki/internal/pkg/ratings"
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRank(t *testing.T) {
+	t.Parallel()
+
+	result := []ratings.Rating{
+		{AssetID: "a-1", Rating: 1400.0},
+		{AssetID: "a-2", Rating: 1600.0},
+		{AssetID: "a-3", Rating: 1500.0},
+	}
+
+	ratings.Rank(result)
+
+	assert.Equal(t, "a-2", result[0].AssetID)
+	assert.Equal(t, 1, result[0].Rank)
+	assert.InDelta(t, 100.0, result[0].Percentile, 0.0001)
+	assert.Equal(t, "a-3", result[1].AssetID)
+	assert.InDelta(t, 50.0, result[1].Percentile, 0.0001)
+	assert.Equal(t, "a-1", result[2].AssetID)
+	assert.InDelta(t, 0.0, result[2].Percentile, 0.0001)
+}
+
+func TestFilterMinGames(t *testing.T) {
+	t.Parallel()
+
+	result := []ratings.Rating{
+		{AssetID: "a-1", Rating: 1600.0, Games: 2},
+		{AssetID: "a-2", Rating: 1500.0, Games: 10},
+		{AssetID: "a-3", Rating: 1400.0, Games: 10},
+	}
+
+	ratings.Rank(result)
+
+	result = ratings.FilterMinGames(result, 5)
+	require.Len(t, result, 2)
+
+	assert.Equal(t, "a-2", result[0].AssetID)
+	assert.Equal(t, 1, result[0].Rank)
+	assert.InDelta(t, 100.0, result[0].Percentile, 0.0001)
+	assert.Equal(t, 2, result[1].Rank)
+}
+
+func TestLoadRating(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+
+	err := service.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		// Ties are broken by asset ID like in Rank.
+		return tx.Bucket([]byte(common.ScorerAssetsBucket)).Put([]byte("a-45"), common.EncodeAssetRecord(common.AssetRecord{
+			Rating: 1504.0,
+		}))
+	})
+	require.NoError(t, err)
+
+	err = service.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		all, err := ratings.LoadRatings(tx, "")
+		require.NoError(t, err)
+
+		for _, expected := range all {
+			rating, err := ratings.LoadRating(tx, "", expected.AssetID)
+			require.NoError(t, err)
+			assert.Equal(t, expected, rating)
+		}
+
+		_, err = ratings.LoadRating(tx, "", "unknown")
+		require.ErrorIs(t, err, ratings.ErrAssetNotFound)
+
+		_, err = ratings.LoadRating(tx, "photos", "a-1")
+		require.ErrorIs(t, err, ratings.ErrAssetNotFound)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func newTestService(t *testing.T) *ratings.RatingsService {
+	t.Helper()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		for idx := range 10 {
+			assetID := []byte(fmt.Sprintf("a-%d", idx))
+
+			err := tx.Bucket([]byte(common.ScorerAssetsBucket)).Put(assetID, common.EncodeAssetRecord(common.AssetRecord{
+				Rating: 1500.0 + float64(idx),
+				Games:  int64(idx),
+			}))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	databaseService := &common.DatabaseService{
+		DB: db,
+	}
+
+	return &ratings.RatingsService{
+		DatabaseService: databaseService,
+		ContestService: &contest.ContestService{
+			DatabaseService: databaseService,
+			Default:         contest.Contest{ID: contest.DefaultContestID},
+		},
+	}
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ratings?limit=2&offset=1&min_games=5", nil)
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, service.GetLeaderboard(echo.New().NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var leaderboard ratings.Leaderboard
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &leaderboard))
+
+	assert.Equal(t, 5, leaderboard.Total)
+	require.Len(t, leaderboard.Ratings, 2)
+	assert.Equal(t, "a-8", leaderboard.Ratings[0].AssetID)
+	assert.Equal(t, 2, leaderboard.Ratings[0].Rank)
+	assert.Equal(t, "a-7", leaderboard.Ratings[1].AssetID)
+}
+
+func TestGetRating(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames("assetID")
+	c.SetParamValues("a-0")
+
+	require.NoError(t, service.GetRating(c))
+
+	var rating ratings.Rating
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rating))
+
+	assert.Equal(t, 10, rating.Rank)
+	assert.InDelta(t, 0.0, rating.Percentile, 0.0001)
+
+	c = e.NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("assetID")
+	c.SetParamValues("unknown")
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, service.GetRating(c), &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.Code)
+}
+
+func TestGetBootstrapInvalidReplicates(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+
+	for _, replicates := range []string{"0", "-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/ratings/bootstrap?replicates="+replicates, nil)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, service.GetBootstrap(echo.New().NewContext(req, httptest.NewRecorder())), &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	}
+}
+
+func TestGetBootstrapBusy(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+	service.Bootstraps = make(chan struct{}, 1)
+	service.Bootstraps <- struct{}{}
+
+	// The client gives up while another bootstrap is running.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/ratings/bootstrap", nil)
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, service.GetBootstrap(echo.New().NewContext(req, httptest.NewRecorder())), &httpErr)
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.Code)
+
+	<-service.Bootstraps
+
+	req = httptest.NewRequest(http.MethodGet, "/api/ratings/bootstrap?replicates=10", nil)
+	require.NoError(t, service.GetBootstrap(echo.New().NewContext(req, httptest.NewRecorder())))
+	assert.Empty(t, service.Bootstraps)
+}
+
+func TestGetLeaderboardContest(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+
+	err := service.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		err := contest.Create(tx, contest.Contest{ID: "photos", Assets: []string{"p-1", "p-2"}, Opponents: 2})
+		if err != nil {
+			return err
+		}
+
+		assets, err := tx.CreateBucket(common.NamespaceBucket(common.ScorerAssetsBucket, "photos"))
+		if err != nil {
+			return err
+		}
+
+		return assets.Put([]byte("p-1"), common.EncodeAssetRecord(common.AssetRecord{Rating: 1510.0}))
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames("contestID")
+	c.SetParamValues("photos")
+
+	require.NoError(t, service.GetLeaderboard(c))
+
+	var leaderboard ratings.Leaderboard
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &leaderboard))
+
+	assert.Equal(t, 1, leaderboard.Total)
+	assert.Equal(t, "p-1", leaderboard.Ratings[0].AssetID)
+
+	c = e.NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("contestID")
+	c.SetParamValues("unknown")
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, service.GetLeaderboard(c), &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.Code)
+}
+
+func TestDownsample(t *testing.T) {
+	t.Parallel()
+
+	history := []scorer.HistoryEntry{
+		{Timestamp: 0, Rating: 1500.0},
+		{Timestamp: 5, Rating: 1510.0},
+		{Timestamp: 10, Rating: 1520.0},
+		{Timestamp: 15, Rating: 1530.0},
+		{Timestamp: 20, Rating: 1540.0},
+	}
+
+	assert.Len(t, ratings.Downsample(history, 0, 0), 5)
+
+	byInterval := ratings.Downsample(history, 10, 0)
+	require.Len(t, byInterval, 3)
+	assert.Equal(t, int64(5), byInterval[0].Timestamp)
+	assert.Equal(t, int64(15), byInterval[1].Timestamp)
+	assert.Equal(t, int64(20), byInterval[2].Timestamp)
+
+	byPoints := ratings.Downsample(history, 0, 2)
+	require.Len(t, byPoints, 2)
+	assert.Equal(t, int64(20), byPoints[1].Timestamp)
+}
+
+func TestLoadMatrix(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t)
+
+	err := service.DatabaseService.DB.Update(func(tx *bolt.Tx) error {
+		require.NoError(t, scorer.RecordPair(tx, "", "a-9", "a-8", scorer.PairWin))
+		require.NoError(t, scorer.RecordPair(tx, "", "a-9", "a-0", scorer.PairWin))
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = service.DatabaseService.DB.View(func(tx *bolt.Tx) error {
+		matrix, err := ratings.LoadMatrix(tx, "", 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"a-9", "a-8"}, matrix.Assets)
+		assert.Equal(t, []ratings.MatrixEntry{
+			{Row: 0, Col: 1, Wins: 1},
+			{Row: 1, Col: 0, Losses: 1},
+		}, matrix.Entries)
+
+		var buf bytes.Buffer
+
+		require.NoError(t, ratings.WriteMatrixCSV(&buf, matrix))
+		assert.Equal(t, "asset_id,opponent_asset_id,wins,losses,ties\na-9,a-8,1,0,0\na-8,a-9,0,1,0\n", buf.String())
+
+		return nil
+	})
+	require.NoError(t, err)
+}
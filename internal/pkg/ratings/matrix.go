@@ -0,0 +1,88 @@
+package ratings
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/vreid/shiki/internal/pkg/scorer"
+	bolt "go.etcd.io/bbolt"
+)
+
+// LoadMatrix builds the head-to-head matrix of the top rated assets of a
+// ratings namespace. A non-positive top includes every rated asset.
+func LoadMatrix(tx *bolt.Tx, namespace string, top int) (Matrix, error) {
+	ratings, err := LoadRatings(tx, namespace)
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	if top > 0 && top < len(ratings) {
+		ratings = ratings[:top]
+	}
+
+	result := Matrix{
+		Assets:  make([]string, 0, len(ratings)),
+		Entries: []MatrixEntry{},
+	}
+
+	index := make(map[string]int, len(ratings))
+
+	for idx, rating := range ratings {
+		result.Assets = append(result.Assets, rating.AssetID)
+		index[rating.AssetID] = idx
+	}
+
+	for row, assetID := range result.Assets {
+		records, err := scorer.LoadPairRecords(tx, namespace, assetID)
+		if err != nil {
+			return Matrix{}, fmt.Errorf("failed to load head-to-head records: %w", err)
+		}
+
+		for _, record := range records {
+			col, ok := index[record.OpponentAssetID]
+			if !ok {
+				continue
+			}
+
+			result.Entries = append(result.Entries, MatrixEntry{
+				Row:    row,
+				Col:    col,
+				Wins:   record.Wins,
+				Losses: record.Losses,
+				Ties:   record.Ties,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// WriteMatrixCSV writes one line per non-empty matrix cell.
+func WriteMatrixCSV(w io.Writer, matrix Matrix) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"asset_id", "opponent_asset_id", "wins", "losses", "ties"})
+	if err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, entry := range matrix.Entries {
+		err := writer.Write([]string{
+			matrix.Assets[entry.Row],
+			matrix.Assets[entry.Col],
+			strconv.FormatInt(entry.Wins, 10),
+			strconv.FormatInt(entry.Losses, 10),
+			strconv.FormatInt(entry.Ties, 10),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write csv record: %w", err)
+		}
+	}
+
+	writer.Flush()
+
+	//nolint:wrapcheck
+	return writer.Error()
+}
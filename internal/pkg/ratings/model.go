@@ -0,0 +1,54 @@
+package ratings
+
+import "github.com/vreid/shiki/internal/pkg/scorer"
+
+type Rating struct {
+	AssetID     string  `json:"asset_id"`
+	Rating      float64 `json:"rating"`
+	Deviation   float64 `json:"deviation"`
+	Games       int64   `json:"games"`
+	Wins        int64   `json:"wins"`
+	Losses      int64   `json:"losses"`
+	Ties        int64   `json:"ties"`
+	FirstSeen   int64   `json:"first_seen"`
+	LastUpdated int64   `json:"last_updated"`
+	Rank        int     `json:"rank"`
+	Percentile  float64 `json:"percentile"`
+}
+
+type Leaderboard struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+
+	Ratings []Rating `json:"ratings"`
+}
+
+type History struct {
+	AssetID string `json:"asset_id"`
+
+	History []scorer.HistoryEntry `json:"history"`
+}
+
+type HeadToHead struct {
+	AssetID string `json:"asset_id"`
+
+	Records []scorer.PairRecord `json:"records"`
+}
+
+// Matrix is a sparse head-to-head matrix in coordinate format. Row and Col
+// index into Assets, and the counts are from the row asset's perspective.
+type Matrix struct {
+	Assets []string `json:"assets"`
+
+	Entries []MatrixEntry `json:"entries"`
+}
+
+type MatrixEntry struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+
+	Wins   int64 `json:"wins"`
+	Losses int64 `json:"losses"`
+	Ties   int64 `json:"ties"`
+}
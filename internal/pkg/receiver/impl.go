@@ -12,21 +12,29 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/auth"
 	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/ratelimit"
 )
 
 type ReceiverService struct {
 	DatabaseService *common.DatabaseService
+	ContestService  *contest.ContestService
 
 	TmpDir string
 }
 
 func NewReceiverService(i do.Injector) (*ReceiverService, error) {
 	databaseService := do.MustInvoke[*common.DatabaseService](i)
+	contestService := do.MustInvoke[*contest.ContestService](i)
+	authService := do.MustInvoke[*auth.AuthService](i)
+	rateLimitService := do.MustInvoke[*ratelimit.RateLimitService](i)
 	tmpDir := do.MustInvokeNamed[string](i, "tmp-dir")
 
 	result := &ReceiverService{
 		DatabaseService: databaseService,
+		ContestService:  contestService,
 		TmpDir:          tmpDir,
 	}
 
@@ -38,9 +46,14 @@ func NewReceiverService(i do.Injector) (*ReceiverService, error) {
 	echoService.Register(func(e *echo.Echo) {
 		apiGroup := e.Group("/api")
 
+		requireUpload := authService.Require(auth.ScopeUpload)
+		limitUpload := rateLimitService.Upload.Middleware()
+
 		receiverGroup := apiGroup.Group("/receiver")
 
-		receiverGroup.POST("/upload", result.Upload)
+		receiverGroup.POST("/upload", result.Upload, requireUpload, limitUpload)
+
+		apiGroup.POST("/contests/:contestID/receiver/upload", result.Upload, requireUpload, limitUpload)
 	})
 
 	return result, nil
@@ -48,6 +61,12 @@ func NewReceiverService(i do.Injector) (*ReceiverService, error) {
 
 //nolint:cyclop,funlen
 func (s *ReceiverService) Upload(c echo.Context) error {
+	contest, err := s.ContestService.FromContext(c)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
 	form, err := c.MultipartForm()
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse multipart form")
@@ -61,7 +80,9 @@ func (s *ReceiverService) Upload(c echo.Context) error {
 	}
 
 	uploadID := _uploadID.String()
-	uploadDir := filepath.Join(s.TmpDir, uploadID)
+
+	// Uploads of the default contest stay where they were before contests.
+	uploadDir := filepath.Join(s.TmpDir, contest.Namespace(), uploadID)
 
 	err = os.MkdirAll(uploadDir, 0700)
 	if err != nil {
@@ -69,6 +90,7 @@ func (s *ReceiverService) Upload(c echo.Context) error {
 	}
 
 	index := UploadIndex{
+		ContestID: contest.Namespace(),
 		UploadID:  uploadID,
 		Timestamp: time.Now(),
 		Files:     make([]string, 0, len(files)),
@@ -3,6 +3,7 @@ package receiver
 import "time"
 
 type UploadIndex struct {
+	ContestID string    `json:"contest_id,omitempty"`
 	UploadID  string    `json:"upload_id"`
 	Timestamp time.Time `json:"timestamp"`
 	Files     []string  `json:"files"`
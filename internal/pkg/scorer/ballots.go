@@ -0,0 +1,84 @@
+package scorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/matchmaker"
+	"go.etcd.io/bbolt"
+)
+
+// NewBallot returns the ballot of an outcome.
+func NewBallot(outcome matchmaker.Outcome, now int64, winnerAssetID string, winnerSlot int) Ballot {
+	matchUp := outcome.SignedMatchUp.MatchUp
+
+	result := Ballot{
+		VoterID:    matchUp.VoterID,
+		Timestamp:  now,
+		Assets:     make([]string, 0, len(matchUp.Opponents)),
+		Winner:     winnerAssetID,
+		WinnerSlot: winnerSlot,
+	}
+
+	for _, opponent := range matchUp.Opponents {
+		result.Assets = append(result.Assets, opponent.AssetID)
+	}
+
+	return result
+}
+
+// RecordBallot stores a ballot of a ratings namespace under its outcome key.
+func RecordBallot(tx *bbolt.Tx, namespace, outcomeKey string, ballot Ballot) error {
+	ballots, err := createNamespaceBucket(tx, common.ScorerBallotsBucket, namespace)
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(ballot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ballot: %w", err)
+	}
+
+	err = ballots.Put([]byte(outcomeKey), value)
+	if err != nil {
+		return fmt.Errorf("failed to put ballot: %w", err)
+	}
+
+	return nil
+}
+
+// ForEachBallot calls f for every ballot of a ratings namespace.
+func ForEachBallot(tx *bbolt.Tx, namespace string, f func(ballot Ballot) error) error {
+	ballots := tx.Bucket(common.NamespaceBucket(common.ScorerBallotsBucket, namespace))
+	if ballots == nil {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return ballots.ForEach(func(k, v []byte) error {
+		var ballot Ballot
+
+		err := json.Unmarshal(v, &ballot)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal ballot %s: %w", k, err)
+		}
+
+		return f(ballot)
+	})
+}
+
+// Slot returns the position the winner was shown in, or -1 for a tie.
+// Ballots stored before WinnerSlot existed find it by the winner.
+func (b Ballot) Slot() int {
+	if b.Winner == "" {
+		return -1
+	}
+
+	if b.WinnerSlot >= 0 && b.WinnerSlot < len(b.Assets) && b.Assets[b.WinnerSlot] == b.Winner {
+		return b.WinnerSlot
+	}
+
+	return slices.Index(b.Assets, b.Winner)
+}
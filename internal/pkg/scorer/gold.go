@@ -0,0 +1,76 @@
+package scorer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/gold"
+	"github.com/vreid/shiki/internal/pkg/matchmaker"
+	"github.com/vreid/shiki/internal/pkg/voter"
+	"go.etcd.io/bbolt"
+)
+
+// GoldAction is what happens to the votes of voters who fail too many gold
+// match-ups.
+type GoldAction string
+
+const (
+	// GoldActionDiscount scales their rating changes by their gold accuracy.
+	GoldActionDiscount GoldAction = "discount"
+
+	// GoldActionQuarantine keeps their outcomes aside without applying them.
+	GoldActionQuarantine GoldAction = "quarantine"
+)
+
+var (
+	ErrUnknownGoldAction        = errors.New("unknown gold action")
+	ErrQuarantineBucketNotFound = errors.New("quarantine bucket doesn't exist")
+)
+
+func ParseGoldAction(value string) (GoldAction, error) {
+	switch action := GoldAction(value); action {
+	case GoldActionDiscount, GoldActionQuarantine:
+		return action, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownGoldAction, value)
+	}
+}
+
+// applyGold grades the answer to a gold match-up. Gold outcomes only count
+// towards the accuracy of their voter and leave the ratings untouched.
+func applyGold(
+	tx *bbolt.Tx,
+	outcome matchmaker.Outcome,
+	now int64,
+	pair gold.Pair,
+	winnerAssetID string,
+	winnerSlot int) error {
+	grade := -1
+	if winnerAssetID == pair.Winner {
+		grade = 1
+	}
+
+	return recordVote(tx, outcome, now, voter.Vote{WinnerSlot: winnerSlot, Gold: grade})
+}
+
+// quarantine keeps an outcome of an unreliable voter aside.
+func quarantine(tx *bbolt.Tx, outcome matchmaker.Outcome) error {
+	quarantined := tx.Bucket([]byte(common.ScorerQuarantineBucket))
+	if quarantined == nil {
+		return ErrQuarantineBucketNotFound
+	}
+
+	value, err := json.Marshal(outcome)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outcome: %w", err)
+	}
+
+	err = quarantined.Put([]byte(OutcomeKey(outcome)), value)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine outcome: %w", err)
+	}
+
+	return nil
+}
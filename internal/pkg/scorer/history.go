@@ -0,0 +1,101 @@
+package scorer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"go.etcd.io/bbolt"
+)
+
+const HistoryEntrySize = 24
+
+var ErrInvalidHistoryEntry = errors.New("invalid history entry")
+
+// AppendHistory records a rating change of an asset. Every asset has its own
+// nested bucket, keyed by a big-endian sequence number so entries stay in
+// insertion order.
+func AppendHistory(tx *bbolt.Tx, namespace, assetID string, entry HistoryEntry) error {
+	history, err := createNamespaceBucket(tx, common.ScorerHistoryBucket, namespace)
+	if err != nil {
+		return err
+	}
+
+	assetHistory, err := history.CreateBucketIfNotExists([]byte(assetID))
+	if err != nil {
+		return fmt.Errorf("failed to create history bucket for %s: %w", assetID, err)
+	}
+
+	seq, err := assetHistory.NextSequence()
+	if err != nil {
+		return fmt.Errorf("failed to generate history sequence: %w", err)
+	}
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+
+	err = assetHistory.Put(key, encodeHistoryEntry(entry))
+	if err != nil {
+		return fmt.Errorf("failed to put history entry: %w", err)
+	}
+
+	return nil
+}
+
+// LoadHistory returns the rating history of an asset, oldest entry first.
+func LoadHistory(tx *bbolt.Tx, namespace, assetID string) ([]HistoryEntry, error) {
+	result := []HistoryEntry{}
+
+	history := tx.Bucket(common.NamespaceBucket(common.ScorerHistoryBucket, namespace))
+	if history == nil {
+		return result, nil
+	}
+
+	assetHistory := history.Bucket([]byte(assetID))
+	if assetHistory == nil {
+		return result, nil
+	}
+
+	err := assetHistory.ForEach(func(_, v []byte) error {
+		entry, err := decodeHistoryEntry(v)
+		if err != nil {
+			return err
+		}
+
+		result = append(result, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", assetID, err)
+	}
+
+	return result, nil
+}
+
+func encodeHistoryEntry(entry HistoryEntry) []byte {
+	buf := make([]byte, HistoryEntrySize)
+
+	//nolint:gosec // Intentional conversion for binary encoding
+	binary.LittleEndian.PutUint64(buf[0:8], uint64(entry.Timestamp))
+	binary.LittleEndian.PutUint64(buf[8:16], math.Float64bits(entry.Rating))
+	//nolint:gosec // Intentional conversion for binary encoding
+	binary.LittleEndian.PutUint64(buf[16:24], uint64(entry.Games))
+
+	return buf
+}
+
+func decodeHistoryEntry(b []byte) (HistoryEntry, error) {
+	if len(b) != HistoryEntrySize {
+		return HistoryEntry{}, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidHistoryEntry, HistoryEntrySize, len(b))
+	}
+
+	//nolint:gosec // Intentional conversion from binary encoding
+	return HistoryEntry{
+		Timestamp: int64(binary.LittleEndian.Uint64(b[0:8])),
+		Rating:    math.Float64frombits(binary.LittleEndian.Uint64(b[8:16])),
+		Games:     int64(binary.LittleEndian.Uint64(b[16:24])),
+	}, nil
+}
@@ -1,44 +1,115 @@
 package scorer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math"
+	"time"
 
 	"github.com/samber/do/v2"
 	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/gold"
 	"github.com/vreid/shiki/internal/pkg/matchmaker"
+	"github.com/vreid/shiki/internal/pkg/queue"
+	"github.com/vreid/shiki/internal/pkg/voter"
 	"go.etcd.io/bbolt"
 )
 
-const DefaultRating = 1500.0
+const (
+	DefaultRating    = 1500.0
+	DefaultDeviation = 350.0
+
+	// MinDeviation keeps the deviation of assets with many games from
+	// collapsing to zero.
+	MinDeviation = 30.0
+
+	// RetryBackoff is the delay before retrying outcomes whose scoring failed.
+	RetryBackoff = 1 * time.Second
+)
 
 var (
-	ErrRatingsBucketNotFound = errors.New("ratings bucket doesn't exist")
-	ErrCountBucketNotFound   = errors.New("count bucket doesn't exist")
+	ErrOutcomesBucketNotFound = errors.New("outcomes bucket doesn't exist")
+	ErrMissingOutcomeKey      = errors.New("outcome has no idempotency key")
 )
 
 type ScorerService struct {
 	DatabaseService *common.DatabaseService
 
-	OutcomeSource <-chan matchmaker.Outcome
+	OutcomeQueue *queue.Queue[matchmaker.Outcome]
+
+	BatchMaxSize    int
+	BatchMaxLatency time.Duration
+
+	Options Options
+
+	stop chan struct{}
+	done chan struct{}
 }
 
 func NewScorerService(i do.Injector) (*ScorerService, error) {
 	databaseService := do.MustInvoke[*common.DatabaseService](i)
-	outcomeSource := do.MustInvokeNamed[<-chan matchmaker.Outcome](i, "outcome-source")
+	outcomeQueue := do.MustInvoke[*queue.Queue[matchmaker.Outcome]](i)
+
+	batchMaxSize := do.MustInvokeNamed[int](i, "scorer-batch-max-size")
+	batchMaxLatencyMs := do.MustInvokeNamed[int](i, "scorer-batch-max-latency-ms")
+
+	voterService := do.MustInvoke[*voter.VoterService](i)
+
+	goldAction, err := ParseGoldAction(do.MustInvokeNamed[string](i, "gold-action"))
+	if err != nil {
+		return nil, err
+	}
 
 	result := &ScorerService{
 		DatabaseService: databaseService,
 
-		OutcomeSource: outcomeSource,
+		OutcomeQueue: outcomeQueue,
+
+		BatchMaxSize:    batchMaxSize,
+		BatchMaxLatency: time.Duration(batchMaxLatencyMs) * time.Millisecond,
+
+		Options: Options{
+			GoldMinAnswers:  int64(do.MustInvokeNamed[int](i, "gold-min-answers")),
+			GoldMinAccuracy: do.MustInvokeNamed[float64](i, "gold-min-accuracy"),
+			GoldAction:      goldAction,
+
+			Timing: voterService.Timing,
+
+			PositionCorrection: do.MustInvokeNamed[bool](i, "position-correction"),
+		},
 	}
 
 	return result, nil
 }
 
 func (s *ScorerService) Start() {
-	go s.processOutcomes()
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		s.processOutcomes()
+	}()
+}
+
+// Shutdown stops the scorer once every queued outcome has been processed.
+// Whatever is left when ctx expires stays in the queue for the next start.
+func (s *ScorerService) Shutdown(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+
+	close(s.stop)
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain outcome queue: %w", ctx.Err())
+	}
 }
 
 func GetKFactor(gamesPlayed int64) float64 {
@@ -62,7 +133,19 @@ func UpdateRatings(
 	winnerCount int64,
 	loserRating float64,
 	loserCount int64) (float64, int64, float64, int64) {
-	expectedWinner := CalculateExpectedScore(winnerRating, loserRating)
+	return UpdateRatingsWithOffset(winnerRating, winnerCount, loserRating, loserCount, 0)
+}
+
+// UpdateRatingsWithOffset is UpdateRatings with offset rating points added to
+// the winner when computing the expected score, e.g. for the advantage of
+// the slot the winner was shown in.
+func UpdateRatingsWithOffset(
+	winnerRating float64,
+	winnerCount int64,
+	loserRating float64,
+	loserCount int64,
+	offset float64) (float64, int64, float64, int64) {
+	expectedWinner := CalculateExpectedScore(winnerRating+offset, loserRating)
 
 	k := (GetKFactor(winnerCount) + GetKFactor(loserCount)) / 2.0
 
@@ -75,79 +158,553 @@ func UpdateRatings(
 		loserCount + 1
 }
 
-//nolint:cyclop,funlen // Database transaction logic requires this complexity and length
-func (s *ScorerService) HandleOutcome(outcome matchmaker.Outcome) {
-	databaseService := s.DatabaseService
+// UpdateDeviation shrinks the rating deviation of an asset by what one game
+// against an opponent reveals, as in Glicko: games against opponents of
+// similar and well-known strength tell the most. weight scales the game like
+// it scales the rating change.
+func UpdateDeviation(deviation, rating, opponentDeviation, opponentRating, weight float64) float64 {
+	if deviation <= 0 {
+		deviation = DefaultDeviation
+	}
+
+	q := math.Ln10 / 400.0
+
+	g := 1.0 / math.Sqrt(1.0+3.0*q*q*opponentDeviation*opponentDeviation/(math.Pi*math.Pi))
+	expected := 1.0 / (1.0 + math.Pow(10, -g*(rating-opponentRating)/400.0))
+
+	information := weight * q * q * g * g * expected * (1.0 - expected)
+
+	return max(1.0/math.Sqrt(1.0/(deviation*deviation)+information), MinDeviation)
+}
+
+// OutcomeKey returns the idempotency key of an outcome. A signed match-up can
+// only ever be decided once, so its signature identifies the outcome.
+func OutcomeKey(outcome matchmaker.Outcome) string {
+	return outcome.SignedMatchUp.Signature
+}
+
+// HandleOutcome applies all pairwise rating updates of an outcome in a single
+// transaction. Outcomes that were already applied are skipped.
+func (s *ScorerService) HandleOutcome(outcome matchmaker.Outcome) error {
+	err := s.DatabaseService.DB.Update(func(tx *bbolt.Tx) error {
+		return ApplyOutcome(tx, outcome, s.Options)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply outcome: %w", err)
+	}
+
+	return nil
+}
+
+// HandleOutcomes applies a batch of outcomes in a single transaction. If the
+// batch fails as a whole, every outcome is retried in its own transaction so
+// one bad outcome cannot hold back the rest.
+func (s *ScorerService) HandleOutcomes(outcomes []matchmaker.Outcome) error {
+	err := s.applyBatch(outcomes)
+	if err == nil {
+		return nil
+	}
+
+	errs := make([]error, 0, len(outcomes))
+
+	for _, outcome := range outcomes {
+		err := s.HandleOutcome(outcome)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outcome %s: %w", OutcomeKey(outcome), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *ScorerService) applyBatch(outcomes []matchmaker.Outcome) error {
+	err := s.DatabaseService.DB.Update(func(tx *bbolt.Tx) error {
+		for _, outcome := range outcomes {
+			err := ApplyOutcome(tx, outcome, s.Options)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply outcomes: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyOutcome applies an outcome within an existing read-write transaction.
+// Outcomes of gold match-ups only grade their voter, and the options decide
+// how much the outcomes of unreliable voters count.
+//
+//nolint:cyclop,funlen // Database transaction logic requires this complexity
+func ApplyOutcome(tx *bbolt.Tx, outcome matchmaker.Outcome, options Options) error {
+	outcomeKey := OutcomeKey(outcome)
+	if len(outcomeKey) == 0 {
+		return ErrMissingOutcomeKey
+	}
 
 	winnerID := outcome.WinnerID
 	winnerAssetID := ""
+	winnerSlot := -1
 
-	for _, opponent := range outcome.SignedMatchUp.MatchUp.Opponents {
+	for idx, opponent := range outcome.SignedMatchUp.MatchUp.Opponents {
 		if winnerID == opponent.OpponentID {
 			winnerAssetID = opponent.AssetID
+			winnerSlot = idx
 
 			break
 		}
 	}
 
-	if len(winnerAssetID) == 0 {
-		return
+	// Only outcomes with a known winner and declared ties are applied,
+	// outcomes without a winner are skipped.
+	if outcome.Tie {
+		if len(winnerID) > 0 {
+			return nil
+		}
+	} else if len(winnerAssetID) == 0 {
+		return nil
+	}
+
+	outcomes := tx.Bucket([]byte(common.ScorerOutcomesBucket))
+	if outcomes == nil {
+		return ErrOutcomesBucketNotFound
+	}
+
+	if outcomes.Get([]byte(outcomeKey)) != nil {
+		return nil
 	}
 
+	namespace := outcome.SignedMatchUp.MatchUp.Namespace()
+	now := time.Now().Unix()
+
+	assetIDs := make([]string, 0, len(outcome.SignedMatchUp.MatchUp.Opponents))
 	for _, opponent := range outcome.SignedMatchUp.MatchUp.Opponents {
-		if winnerID == opponent.OpponentID {
+		assetIDs = append(assetIDs, opponent.AssetID)
+	}
+
+	pair, isGold, err := gold.Lookup(tx, namespace, assetIDs)
+	if err != nil {
+		return fmt.Errorf("failed to look up gold pair: %w", err)
+	}
+
+	weight, quarantined, err := voterWeight(tx, outcome, options)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isGold:
+		err = applyGold(tx, outcome, now, pair, winnerAssetID, winnerSlot)
+	case quarantined:
+		err = quarantine(tx, outcome)
+		if err == nil {
+			err = recordVote(tx, outcome, now, voter.Vote{WinnerSlot: winnerSlot})
+		}
+	case outcome.Tie:
+		err = applyTie(tx, outcome, now)
+	default:
+		err = applyWin(tx, outcome, now, winnerSlot, weight, options)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Gold outcomes say nothing new about the assets.
+	if !isGold {
+		err = RecordBallot(tx, namespace, outcomeKey, NewBallot(outcome, now, winnerAssetID, winnerSlot))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = outcomes.Put([]byte(outcomeKey), OutcomeLogEntry(now, outcome))
+	if err != nil {
+		return fmt.Errorf("failed to put outcome key: %w", err)
+	}
+
+	return nil
+}
+
+// applyWin updates the ratings of the winner against every other opponent,
+// with the rating changes scaled by weight.
+//
+//nolint:funlen // Database transaction logic requires this length
+func applyWin(tx *bbolt.Tx, outcome matchmaker.Outcome, now int64, winnerSlot int, weight float64, options Options) error {
+	matchUp := outcome.SignedMatchUp.MatchUp
+	namespace := matchUp.Namespace()
+	winnerAssetID := matchUp.Opponents[winnerSlot].AssetID
+
+	positions, err := LoadPositionStats(tx, namespace, len(matchUp.Opponents))
+	if err != nil {
+		return err
+	}
+
+	offsets := make([]float64, len(matchUp.Opponents))
+	if options.PositionCorrection && positions.Votes >= PositionMinVotes {
+		offsets = positions.Offsets()
+	}
+
+	assets, err := createNamespaceBucket(tx, common.ScorerAssetsBucket, namespace)
+	if err != nil {
+		return err
+	}
+
+	records, err := loadRecords(assets, outcome)
+	if err != nil {
+		return err
+	}
+
+	consensus := Consensus(records, matchUp.Opponents, winnerAssetID)
+
+	winner := records[winnerAssetID]
+
+	for loserSlot, opponent := range matchUp.Opponents {
+		if outcome.WinnerID == opponent.OpponentID {
 			continue
 		}
 
-		_ = databaseService.DB.Update(func(tx *bbolt.Tx) error {
-			ratings := tx.Bucket([]byte(common.ScorerRatingsBucket))
-			if ratings == nil {
-				return ErrRatingsBucketNotFound
-			}
+		loserAssetID := opponent.AssetID
+		loser := records[loserAssetID]
 
-			count := tx.Bucket([]byte(common.ScorerCountBucket))
-			if count == nil {
-				return ErrCountBucketNotFound
-			}
+		winnerRating, winnerGames, loserRating, loserGames := UpdateRatingsWithOffset(
+			winner.Rating, winner.Games, loser.Rating, loser.Games, offsets[winnerSlot]-offsets[loserSlot])
 
-			loserAssetID := opponent.AssetID
+		winner.Deviation, loser.Deviation =
+			UpdateDeviation(winner.Deviation, winner.Rating, loser.Deviation, loser.Rating, weight),
+			UpdateDeviation(loser.Deviation, loser.Rating, winner.Deviation, winner.Rating, weight)
 
-			winnerRating := common.BytesToFloat64(ratings.Get([]byte(winnerAssetID)), DefaultRating)
-			winnerCount := common.BytesToInt64(count.Get([]byte(winnerAssetID)), 0)
+		winner.Rating += weight * (winnerRating - winner.Rating)
+		loser.Rating += weight * (loserRating - loser.Rating)
+		winner.Games, loser.Games = winnerGames, loserGames
 
-			loserRating := common.BytesToFloat64(ratings.Get([]byte(loserAssetID)), DefaultRating)
-			loserCount := common.BytesToInt64(count.Get([]byte(loserAssetID)), 0)
+		winner.Wins++
+		loser.Losses++
 
-			winnerRating, winnerCount, loserRating, loserCount =
-				UpdateRatings(winnerRating, winnerCount, loserRating, loserCount)
+		err := RecordPair(tx, namespace, winnerAssetID, loserAssetID, PairWin)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = putRecords(assets, records, now)
+	if err != nil {
+		return err
+	}
 
-			err := ratings.Put([]byte(winnerAssetID), common.Float64ToBytes(winnerRating))
+	for _, opponent := range matchUp.Opponents {
+		record := records[opponent.AssetID]
+
+		err := AppendHistory(tx, namespace, opponent.AssetID, HistoryEntry{
+			Timestamp: now,
+			Rating:    record.Rating,
+			Games:     record.Games,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = recordPosition(tx, namespace, len(matchUp.Opponents), winnerSlot)
+	if err != nil {
+		return err
+	}
+
+	return recordVote(tx, outcome, now, voter.Vote{WinnerSlot: winnerSlot, Consensus: consensus})
+}
+
+// applyTie records a declared tie as a tie between every pair of opponents.
+// Ties only show up in the tie counts and head-to-head records, ratings are
+// left untouched.
+func applyTie(tx *bbolt.Tx, outcome matchmaker.Outcome, now int64) error {
+	namespace := outcome.SignedMatchUp.MatchUp.Namespace()
+
+	assets, err := createNamespaceBucket(tx, common.ScorerAssetsBucket, namespace)
+	if err != nil {
+		return err
+	}
+
+	records, err := loadRecords(assets, outcome)
+	if err != nil {
+		return err
+	}
+
+	opponents := outcome.SignedMatchUp.MatchUp.Opponents
+
+	for idx, opponent := range opponents {
+		for _, other := range opponents[idx+1:] {
+			records[opponent.AssetID].Ties++
+			records[other.AssetID].Ties++
+
+			err := RecordPair(tx, namespace, opponent.AssetID, other.AssetID, PairTie)
 			if err != nil {
-				return fmt.Errorf("failed to put winner rating: %w", err)
+				return err
 			}
+		}
+	}
 
-			err = count.Put([]byte(winnerAssetID), common.Int64ToBytes(winnerCount))
-			if err != nil {
-				return fmt.Errorf("failed to put winner count: %w", err)
+	err = putRecords(assets, records, now)
+	if err != nil {
+		return err
+	}
+
+	return recordVote(tx, outcome, now, voter.Vote{WinnerSlot: -1})
+}
+
+// OutcomeLogEntry is the value an outcome is logged with in the outcomes
+// bucket: the time it was applied, followed by the ID of the voter the
+// match-up was issued to, if any.
+func OutcomeLogEntry(now int64, outcome matchmaker.Outcome) []byte {
+	return append(common.Int64ToBytes(now), outcome.SignedMatchUp.MatchUp.VoterID...)
+}
+
+// Consensus returns 1 if the winner was the favourite by rating before the
+// outcome, -1 if another opponent was and 0 without a single favourite.
+func Consensus(records map[string]*common.AssetRecord, opponents []matchmaker.Opponent, winnerAssetID string) int {
+	favourite := ""
+	best := math.Inf(-1)
+	unique := false
+
+	for _, opponent := range opponents {
+		rating := records[opponent.AssetID].Rating
+
+		switch {
+		case rating > best:
+			favourite, best, unique = opponent.AssetID, rating, true
+		case rating == best && opponent.AssetID != favourite:
+			unique = false
+		}
+	}
+
+	switch {
+	case !unique:
+		return 0
+	case favourite == winnerAssetID:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// recordVote adds an outcome to the statistics of its voter, vote holds what
+// the caller learned from it. Outcomes of match-ups issued before voters were
+// tracked have no voter.
+func recordVote(tx *bbolt.Tx, outcome matchmaker.Outcome, now int64, vote voter.Vote) error {
+	matchUp := outcome.SignedMatchUp.MatchUp
+	if matchUp.VoterID == "" {
+		return nil
+	}
+
+	vote.VoterID = matchUp.VoterID
+	vote.Timestamp = now
+	vote.DurationMs = outcome.DeliberationMs()
+	vote.Opponents = len(matchUp.Opponents)
+
+	//nolint:wrapcheck
+	return voter.Record(tx, vote)
+}
+
+// createNamespaceBucket returns a scorer bucket of a ratings namespace and
+// creates it on first use.
+func createNamespaceBucket(tx *bbolt.Tx, bucket, namespace string) (*bbolt.Bucket, error) {
+	result, err := tx.CreateBucketIfNotExists(common.NamespaceBucket(bucket, namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s bucket: %w", common.NamespaceBucket(bucket, namespace), err)
+	}
+
+	return result, nil
+}
+
+// LoadRecord returns the record of an asset, or a fresh record if the asset
+// has not been scored yet.
+func LoadRecord(assets *bbolt.Bucket, assetID string) (common.AssetRecord, error) {
+	value := assets.Get([]byte(assetID))
+	if value == nil {
+		return common.AssetRecord{
+			Rating:    DefaultRating,
+			Deviation: DefaultDeviation,
+		}, nil
+	}
+
+	record, err := common.DecodeAssetRecord(value)
+	if err != nil {
+		return common.AssetRecord{}, fmt.Errorf("failed to decode record of %s: %w", assetID, err)
+	}
+
+	return record, nil
+}
+
+func loadRecords(assets *bbolt.Bucket, outcome matchmaker.Outcome) (map[string]*common.AssetRecord, error) {
+	records := map[string]*common.AssetRecord{}
+
+	for _, opponent := range outcome.SignedMatchUp.MatchUp.Opponents {
+		if _, ok := records[opponent.AssetID]; ok {
+			continue
+		}
+
+		record, err := LoadRecord(assets, opponent.AssetID)
+		if err != nil {
+			return nil, err
+		}
+
+		records[opponent.AssetID] = &record
+	}
+
+	return records, nil
+}
+
+func putRecords(assets *bbolt.Bucket, records map[string]*common.AssetRecord, now int64) error {
+	for assetID, record := range records {
+		if record.FirstSeen == 0 {
+			record.FirstSeen = now
+		}
+
+		record.LastUpdated = now
+
+		err := assets.Put([]byte(assetID), common.EncodeAssetRecord(*record))
+		if err != nil {
+			return fmt.Errorf("failed to put record of %s: %w", assetID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ScorerService) processOutcomes() {
+	batchMaxSize := max(s.BatchMaxSize, 1)
+
+	for {
+		entries, err := s.OutcomeQueue.Peek(batchMaxSize)
+		if err != nil {
+			log.Printf("failed to read outcome queue: %v", err)
+
+			if !s.sleep(RetryBackoff) {
+				return
+			}
+
+			continue
+		}
+
+		if len(entries) == 0 {
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+
+			select {
+			case <-s.OutcomeQueue.Notify():
+			case <-s.stop:
+				continue
 			}
 
-			err = ratings.Put([]byte(loserAssetID), common.Float64ToBytes(loserRating))
+			// Give concurrent clients a moment to fill the batch.
+			s.sleep(s.BatchMaxLatency)
+
+			continue
+		}
+
+		if !s.processEntries(entries) && !s.sleep(RetryBackoff) {
+			return
+		}
+	}
+}
+
+// sleep waits for d and reports whether the scorer should keep running.
+func (s *ScorerService) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.stop:
+		return false
+	}
+}
+
+// processEntries scores a batch of queued outcomes and acknowledges them. It
+// reports whether every entry was either acknowledged or dead-lettered.
+//
+//nolint:cyclop // Acknowledgement bookkeeping requires this complexity
+func (s *ScorerService) processEntries(entries []queue.Entry[matchmaker.Outcome]) bool {
+	outcomes := make([]matchmaker.Outcome, 0, len(entries))
+	ids := make([]uint64, 0, len(entries))
+	dead := []uint64{}
+
+	for _, entry := range entries {
+		if entry.Err != nil {
+			log.Printf("dropping outcome %d: %v", entry.ID, entry.Err)
+
+			dead = append(dead, entry.ID)
+
+			continue
+		}
+
+		outcomes = append(outcomes, entry.Value)
+		ids = append(ids, entry.ID)
+	}
+
+	// The outcomes are acknowledged in the transaction that scores them, so
+	// a batch costs a single write.
+	err := s.DatabaseService.DB.Update(func(tx *bbolt.Tx) error {
+		for _, outcome := range outcomes {
+			err := ApplyOutcome(tx, outcome, s.Options)
 			if err != nil {
-				return fmt.Errorf("failed to put loser rating: %w", err)
+				return err
 			}
+		}
+
+		err := s.OutcomeQueue.AckTx(tx, ids...)
+		if err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+
+		//nolint:wrapcheck
+		return s.OutcomeQueue.DeadLetterTx(tx, dead...)
+	})
+	if err == nil {
+		return true
+	}
+
+	complete := true
 
-			err = count.Put([]byte(loserAssetID), common.Int64ToBytes(loserCount))
+	// Retry one by one, so a single bad outcome cannot stall the queue.
+	for idx, outcome := range outcomes {
+		err := s.DatabaseService.DB.Update(func(tx *bbolt.Tx) error {
+			err := ApplyOutcome(tx, outcome, s.Options)
 			if err != nil {
-				return fmt.Errorf("failed to put loser count: %w", err)
+				return err
 			}
 
-			return nil
+			//nolint:wrapcheck
+			return s.OutcomeQueue.AckTx(tx, ids[idx])
 		})
+
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrMissingOutcomeKey):
+			log.Printf("dropping outcome %d: %v", ids[idx], err)
+
+			dead = append(dead, ids[idx])
+		default:
+			log.Printf("failed to handle outcome %s: %v", OutcomeKey(outcome), err)
+
+			complete = false
+		}
 	}
-}
 
-func (s *ScorerService) processOutcomes() {
-	for outcome := range s.OutcomeSource {
-		s.HandleOutcome(outcome)
+	err = s.OutcomeQueue.DeadLetter(dead...)
+	if err != nil {
+		log.Printf("failed to dead-letter outcomes: %v", err)
+
+		complete = false
 	}
+
+	return complete
 }
@@ -1,16 +1,20 @@
 package scorer_test
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/gold"
 	"github.com/vreid/shiki/internal/pkg/matchmaker"
+	"github.com/vreid/shiki/internal/pkg/queue"
 	scorer "github.com/vreid/shiki/internal/pkg/scorer"
+	"github.com/vreid/shiki/internal/pkg/testutil"
+	"github.com/vreid/shiki/internal/pkg/voter"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -37,43 +41,17 @@ func TestUpdateRatings(t *testing.T) {
 	assert.Equal(t, int64(101), newLoserCount)
 }
 
-//nolint:funlen // Test setup requires comprehensive initialization
-func TestHandleOutcome(t *testing.T) {
-	t.Parallel()
-
-	tmpPath := filepath.Join(t.TempDir(), "shiki-test.db")
-
-	db, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
-	require.NoError(t, err)
-
-	defer func() {
-		_ = db.Close()
-	}()
-
-	err = db.Update(func(tx *bolt.Tx) error {
-		for _, bucket := range []string{
-			common.ScorerRatingsBucket,
-			common.ScorerCountBucket,
-		} {
-			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
-			if err != nil {
-				return fmt.Errorf("failed to create %s bucket: %w", bucket, err)
-			}
-		}
+func loadRecord(t *testing.T, assets *bolt.Bucket, assetID string) common.AssetRecord {
+	t.Helper()
 
-		return nil
-	})
+	record, err := scorer.LoadRecord(assets, assetID)
 	require.NoError(t, err)
 
-	databaseService := &common.DatabaseService{
-		DB: db,
-	}
-
-	scorerService := &scorer.ScorerService{
-		DatabaseService: databaseService,
-	}
+	return record
+}
 
-	scorerService.HandleOutcome(matchmaker.Outcome{
+func testOutcome(signature string) matchmaker.Outcome {
+	return matchmaker.Outcome{
 		WinnerID: "o-1",
 		SignedMatchUp: matchmaker.SignedMatchUp{
 			MatchUp: matchmaker.MatchUp{
@@ -96,23 +74,623 @@ func TestHandleOutcome(t *testing.T) {
 					},
 				},
 			},
+			Signature: signature,
+		},
+	}
+}
+
+func TestHandleOutcome(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	databaseService := &common.DatabaseService{
+		DB: db,
+	}
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: databaseService,
+	}
+
+	err := scorerService.HandleOutcome(testOutcome("s-1"))
+	require.NoError(t, err)
+
+	err = db.View(func(tx *bolt.Tx) error {
+		assets := tx.Bucket([]byte(common.ScorerAssetsBucket))
+
+		winner := loadRecord(t, assets, "a-1")
+		assert.InEpsilon(t, 1659.0, winner.Rating, 1.0)
+		assert.Equal(t, int64(3), winner.Games)
+		assert.Equal(t, int64(3), winner.Wins)
+		assert.NotZero(t, winner.FirstSeen)
+		assert.Equal(t, winner.FirstSeen, winner.LastUpdated)
+
+		loser2 := loadRecord(t, assets, "a-2")
+		assert.InEpsilon(t, 1436.0, loser2.Rating, 1.0)
+		assert.Equal(t, int64(1), loser2.Losses)
+
+		assert.InEpsilon(t, 1447.0, loadRecord(t, assets, "a-3").Rating, 1.0)
+		assert.InEpsilon(t, 1456.0, loadRecord(t, assets, "a-4").Rating, 1.0)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestHandleOutcomeIdempotent(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+	}
+
+	require.NoError(t, scorerService.HandleOutcome(testOutcome("s-1")))
+	require.NoError(t, scorerService.HandleOutcome(testOutcome("s-1")))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		assets := tx.Bucket([]byte(common.ScorerAssetsBucket))
+
+		assert.Equal(t, int64(3), loadRecord(t, assets, "a-1").Games)
+		assert.Equal(t, int64(1), loadRecord(t, assets, "a-2").Games)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, scorerService.HandleOutcome(testOutcome("")), scorer.ErrMissingOutcomeKey)
+}
+
+func TestHandleOutcomeHistory(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+	}
+
+	require.NoError(t, scorerService.HandleOutcome(testOutcome("s-1")))
+	require.NoError(t, scorerService.HandleOutcome(testOutcome("s-2")))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		history, err := scorer.LoadHistory(tx, "", "a-1")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+
+		assert.InEpsilon(t, 1659.0, history[0].Rating, 1.0)
+		assert.Equal(t, int64(3), history[0].Games)
+		assert.Equal(t, int64(6), history[1].Games)
+
+		history, err = scorer.LoadHistory(tx, "", "unknown")
+		require.NoError(t, err)
+		assert.Empty(t, history)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestHandleOutcomePairs(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+	}
+
+	tie := testOutcome("s-2")
+	tie.WinnerID = ""
+	tie.Tie = true
+
+	require.NoError(t, scorerService.HandleOutcome(testOutcome("s-1")))
+	require.NoError(t, scorerService.HandleOutcome(tie))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		records, err := scorer.LoadPairRecords(tx, "", "a-1")
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, scorer.PairRecord{OpponentAssetID: "a-2", Wins: 1, Ties: 1}, records[0])
+
+		records, err = scorer.LoadPairRecords(tx, "", "a-2")
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, scorer.PairRecord{OpponentAssetID: "a-1", Losses: 1, Ties: 1}, records[0])
+		assert.Equal(t, scorer.PairRecord{OpponentAssetID: "a-3", Ties: 1}, records[1])
+
+		record := loadRecord(t, tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-2")
+		assert.Equal(t, int64(1), record.Losses)
+		assert.Equal(t, int64(3), record.Ties)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestUpdateDeviation(t *testing.T) {
+	t.Parallel()
+
+	deviation := scorer.UpdateDeviation(scorer.DefaultDeviation, 1500.0, scorer.DefaultDeviation, 1500.0, 1)
+	assert.InDelta(t, 290.23, deviation, 0.01)
+
+	// Discounted votes tell less.
+	discounted := scorer.UpdateDeviation(scorer.DefaultDeviation, 1500.0, scorer.DefaultDeviation, 1500.0, 0.1)
+	assert.InDelta(t, 342.31, discounted, 0.01)
+
+	// A lopsided game tells less than an even one.
+	lopsided := scorer.UpdateDeviation(scorer.DefaultDeviation, 1500.0, scorer.DefaultDeviation, 2100.0, 1)
+	assert.Greater(t, lopsided, deviation)
+
+	for range 10000 {
+		deviation = scorer.UpdateDeviation(deviation, 1500.0, scorer.MinDeviation, 1500.0, 1)
+	}
+
+	assert.InDelta(t, scorer.MinDeviation, deviation, 0.0001)
+}
+
+func TestHandleOutcomeWithoutWinner(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+	}
+
+	skipped := testOutcome("s-1")
+	skipped.WinnerID = ""
+
+	invalid := testOutcome("s-2")
+	invalid.Tie = true
+
+	require.NoError(t, scorerService.HandleOutcome(skipped))
+	require.NoError(t, scorerService.HandleOutcome(invalid))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket([]byte(common.ScorerOutcomesBucket)).Get([]byte("s-1")))
+		assert.Nil(t, tx.Bucket([]byte(common.ScorerOutcomesBucket)).Get([]byte("s-2")))
+		assert.Nil(t, tx.Bucket([]byte(common.ScorerAssetsBucket)).Get([]byte("a-1")))
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestHandleOutcomeContest(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+	}
+
+	outcome := testOutcome("s-1")
+	outcome.SignedMatchUp.MatchUp.ContestID = "photos"
+
+	require.NoError(t, scorerService.HandleOutcome(outcome))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket([]byte(common.ScorerAssetsBucket)).Get([]byte("a-1")))
+
+		assets := tx.Bucket(common.NamespaceBucket(common.ScorerAssetsBucket, "photos"))
+		require.NotNil(t, assets)
+		assert.Equal(t, int64(3), loadRecord(t, assets, "a-1").Wins)
+		assert.Less(t, loadRecord(t, assets, "a-1").Deviation, scorer.DefaultDeviation)
+
+		history, err := scorer.LoadHistory(tx, "photos", "a-1")
+		require.NoError(t, err)
+		assert.Len(t, history, 1)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestHandleOutcomeVoter(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+	}
+
+	outcome := testOutcome("s-1")
+	outcome.SignedMatchUp.MatchUp.VoterID = "user:user-1"
+	outcome.ReceivedAtMs = outcome.SignedMatchUp.MatchUp.Timestamp*1000 + 2500
+
+	require.NoError(t, scorerService.HandleOutcome(outcome))
+
+	tie := testOutcome("s-2")
+	tie.SignedMatchUp.MatchUp.VoterID = "user:user-1"
+	tie.WinnerID = ""
+	tie.Tie = true
+
+	require.NoError(t, scorerService.HandleOutcome(tie))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		entry := tx.Bucket([]byte(common.ScorerOutcomesBucket)).Get([]byte("s-1"))
+		require.Len(t, entry, 8+len("user:user-1"))
+		assert.Equal(t, "user:user-1", string(entry[8:]))
+
+		stats, err := voter.Load(tx, "user:user-1")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), stats.Votes)
+		assert.Equal(t, int64(1), stats.Ties)
+		assert.Equal(t, int64(1), stats.TimedVotes)
+		assert.Equal(t, int64(2500), stats.TotalTimeMs)
+		assert.Equal(t, []int64{1}, stats.PositionWins)
+
+		// Every asset started at the same rating, so there was no favourite.
+		assert.Equal(t, int64(0), stats.Agreements+stats.Disagreements)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestHandleOutcomeGold(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+		Options: scorer.Options{
+			GoldMinAnswers:  1,
+			GoldMinAccuracy: 0.5,
+			GoldAction:      scorer.GoldActionQuarantine,
+		},
+	}
+
+	pair, err := gold.NewPair("", "", []string{"a-4", "a-3", "a-2", "a-1"}, "a-2")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		return gold.Add(tx, pair)
+	}))
+
+	// a-1 wins, but the gold pair says a-2 should have.
+	wrong := testOutcome("s-1")
+	wrong.SignedMatchUp.MatchUp.VoterID = "user:user-1"
+
+	require.NoError(t, scorerService.HandleOutcome(wrong))
+
+	regular := testOutcome("s-2")
+	regular.SignedMatchUp.MatchUp.VoterID = "user:user-1"
+	regular.SignedMatchUp.MatchUp.Opponents = regular.SignedMatchUp.MatchUp.Opponents[:3]
+
+	require.NoError(t, scorerService.HandleOutcome(regular))
+
+	err = db.View(func(tx *bolt.Tx) error {
+		assets := tx.Bucket([]byte(common.ScorerAssetsBucket))
+		assert.Nil(t, assets.Get([]byte("a-1")))
+
+		assert.NotNil(t, tx.Bucket([]byte(common.ScorerOutcomesBucket)).Get([]byte("s-1")))
+		assert.NotNil(t, tx.Bucket([]byte(common.ScorerQuarantineBucket)).Get([]byte("s-2")))
+
+		stats, err := voter.Load(tx, "user:user-1")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), stats.Votes)
+		assert.Equal(t, int64(1), stats.GoldAnswers)
+		assert.Equal(t, int64(0), stats.GoldCorrect)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestOptionsWeight(t *testing.T) {
+	t.Parallel()
+
+	options := scorer.Options{
+		GoldMinAnswers:  4,
+		GoldMinAccuracy: 0.6,
+		GoldAction:      scorer.GoldActionDiscount,
+	}
+
+	weight, quarantined := options.Weight(voter.Stats{GoldAnswers: 3})
+	assert.InDelta(t, 1.0, weight, 0.0001)
+	assert.False(t, quarantined)
+
+	weight, quarantined = options.Weight(voter.Stats{GoldAnswers: 4, GoldCorrect: 2})
+	assert.InDelta(t, 0.5, weight, 0.0001)
+	assert.False(t, quarantined)
+
+	weight, _ = options.Weight(voter.Stats{GoldAnswers: 4, GoldCorrect: 3})
+	assert.InDelta(t, 1.0, weight, 0.0001)
+
+	options.GoldAction = scorer.GoldActionQuarantine
+
+	_, quarantined = options.Weight(voter.Stats{GoldAnswers: 4, GoldCorrect: 2})
+	assert.True(t, quarantined)
+
+	options.Timing = voter.TimingPolicy{MinMeanMs: 1000, Action: voter.TimingActionDiscount}
+
+	fast := voter.Stats{RecentTimesMs: make([]int64, voter.RecentVotes)}
+
+	weight, quarantined = options.Weight(fast)
+	assert.InDelta(t, scorer.TimingDiscount, weight, 0.0001)
+	assert.False(t, quarantined)
+}
+
+func TestHandleOutcomeTimingFlagged(t *testing.T) {
+	t.Parallel()
+
+	gain := func(flagged bool) float64 {
+		db := testutil.OpenMigratedDatabase(t)
+
+		scorerService := &scorer.ScorerService{
+			DatabaseService: &common.DatabaseService{
+				DB: db,
+			},
+			Options: scorer.Options{
+				Timing: voter.TimingPolicy{MinMeanMs: 1000, Action: voter.TimingActionDiscount},
+			},
+		}
+
+		outcome := testOutcome("s-1")
+		outcome.SignedMatchUp.MatchUp.VoterID = "anon:anon-1"
+		outcome.TimingFlagged = flagged
+
+		require.NoError(t, scorerService.HandleOutcome(outcome))
+
+		var result float64
+
+		err := db.View(func(tx *bolt.Tx) error {
+			result = loadRecord(t, tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-1").Rating - scorer.DefaultRating
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		return result
+	}
+
+	full, discounted := gain(false), gain(true)
+
+	assert.Positive(t, discounted)
+	assert.Less(t, discounted, 2*scorer.TimingDiscount*full)
+}
+
+func TestConsensus(t *testing.T) {
+	t.Parallel()
+
+	opponents := []matchmaker.Opponent{{AssetID: "a-1"}, {AssetID: "a-2"}, {AssetID: "a-3"}}
+	records := map[string]*common.AssetRecord{
+		"a-1": {Rating: 1600},
+		"a-2": {Rating: 1500},
+		"a-3": {Rating: 1600},
+	}
+
+	assert.Equal(t, 0, scorer.Consensus(records, opponents, "a-1"))
+
+	records["a-3"].Rating = 1400
+
+	assert.Equal(t, 1, scorer.Consensus(records, opponents, "a-1"))
+	assert.Equal(t, -1, scorer.Consensus(records, opponents, "a-2"))
+}
+
+func TestHandleOutcomesFallback(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
 		},
+	}
+
+	err := scorerService.HandleOutcomes([]matchmaker.Outcome{
+		testOutcome("s-1"),
+		testOutcome(""),
+		testOutcome("s-2"),
 	})
+	require.ErrorIs(t, err, scorer.ErrMissingOutcomeKey)
 
 	err = db.View(func(tx *bolt.Tx) error {
-		ratings := tx.Bucket([]byte(common.ScorerRatingsBucket))
+		assert.Equal(t, int64(6), loadRecord(t, tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-1").Games)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	t.Parallel()
 
-		winner := ratings.Get([]byte("a-1"))
-		loser2 := ratings.Get([]byte("a-2"))
-		loser3 := ratings.Get([]byte("a-3"))
-		loser4 := ratings.Get([]byte("a-4"))
+	db := testutil.OpenMigratedDatabase(t)
 
-		assert.InEpsilon(t, 1659.0, common.BytesToFloat64(winner, 1500.0), 1.0)
-		assert.InEpsilon(t, 1436.0, common.BytesToFloat64(loser2, 1500.0), 1.0)
-		assert.InEpsilon(t, 1447.0, common.BytesToFloat64(loser3, 1500.0), 1.0)
-		assert.InEpsilon(t, 1456.0, common.BytesToFloat64(loser4, 1500.0), 1.0)
+	outcomeQueue, err := queue.NewQueue[matchmaker.Outcome](db, common.OutcomeQueueBucket, 100)
+	require.NoError(t, err)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+		OutcomeQueue:    outcomeQueue,
+		BatchMaxSize:    2,
+		BatchMaxLatency: time.Millisecond,
+	}
+
+	scorerService.Start()
+
+	for i := range 5 {
+		require.NoError(t, outcomeQueue.Enqueue(testOutcome(fmt.Sprintf("s-%d", i))))
+	}
+
+	require.NoError(t, outcomeQueue.Enqueue(testOutcome("")))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, scorerService.Shutdown(ctx))
+	assert.Equal(t, 0, outcomeQueue.Len())
+
+	err = db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, int64(15), loadRecord(t, tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-1").Games)
 
 		return nil
 	})
 	require.NoError(t, err)
 }
+
+func benchmarkOutcomes(n int) []matchmaker.Outcome {
+	outcomes := make([]matchmaker.Outcome, 0, n)
+
+	for i := range n {
+		outcome := testOutcome(fmt.Sprintf("s-%d", i))
+
+		for j := range outcome.SignedMatchUp.MatchUp.Opponents {
+			outcome.SignedMatchUp.MatchUp.Opponents[j].AssetID = fmt.Sprintf("a-%d", (i+j)%64)
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	return outcomes
+}
+
+func BenchmarkHandleOutcome(b *testing.B) {
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: testutil.OpenMigratedDatabase(b),
+		},
+	}
+
+	outcomes := benchmarkOutcomes(256)
+	total := 0
+
+	for b.Loop() {
+		for _, outcome := range outcomes {
+			outcome.SignedMatchUp.Signature = fmt.Sprintf("%s-%d", outcome.SignedMatchUp.Signature, total)
+
+			err := scorerService.HandleOutcome(outcome)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		total++
+	}
+
+	b.ReportMetric(float64(total*len(outcomes))/b.Elapsed().Seconds(), "outcomes/s")
+}
+
+func BenchmarkHandleOutcomes(b *testing.B) {
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: testutil.OpenMigratedDatabase(b),
+		},
+	}
+
+	outcomes := benchmarkOutcomes(256)
+	total := 0
+
+	for b.Loop() {
+		batch := make([]matchmaker.Outcome, 0, len(outcomes))
+
+		for _, outcome := range outcomes {
+			outcome.SignedMatchUp.Signature = fmt.Sprintf("%s-%d", outcome.SignedMatchUp.Signature, total)
+			batch = append(batch, outcome)
+		}
+
+		err := scorerService.HandleOutcomes(batch)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		total++
+	}
+
+	b.ReportMetric(float64(total*len(outcomes))/b.Elapsed().Seconds(), "outcomes/s")
+}
+
+func TestPositionCorrection(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.OpenMigratedDatabase(t)
+
+	scorerService := &scorer.ScorerService{
+		DatabaseService: &common.DatabaseService{
+			DB: db,
+		},
+		Options: scorer.Options{
+			PositionCorrection: true,
+		},
+	}
+
+	// The first slot wins every time.
+	for idx := range scorer.PositionMinVotes {
+		outcome := testOutcome(fmt.Sprintf("s-%d", idx))
+		outcome.SignedMatchUp.MatchUp.Opponents = outcome.SignedMatchUp.MatchUp.Opponents[:2]
+
+		if idx%2 == 1 {
+			outcome.WinnerID = "o-2"
+			outcome.SignedMatchUp.MatchUp.Opponents = []matchmaker.Opponent{
+				{OpponentID: "o-2", AssetID: "a-2"},
+				{OpponentID: "o-1", AssetID: "a-1"},
+			}
+		}
+
+		require.NoError(t, scorerService.HandleOutcome(outcome))
+	}
+
+	err := db.View(func(tx *bolt.Tx) error {
+		positions, err := scorer.LoadPositionStats(tx, "", 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(scorer.PositionMinVotes), positions.Votes)
+		assert.Equal(t, []int64{scorer.PositionMinVotes, 0}, positions.Wins)
+
+		offsets := positions.Offsets()
+		assert.Greater(t, offsets[0], 0.0)
+		assert.Less(t, offsets[1], 0.0)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	var before, after common.AssetRecord
+
+	_ = db.View(func(tx *bolt.Tx) error {
+		before = loadRecord(t, tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-1")
+
+		return nil
+	})
+
+	// A win from the favoured slot is worth less than an even win.
+	outcome := testOutcome("s-corrected")
+	outcome.SignedMatchUp.MatchUp.Opponents = outcome.SignedMatchUp.MatchUp.Opponents[:2]
+
+	require.NoError(t, scorerService.HandleOutcome(outcome))
+
+	_ = db.View(func(tx *bolt.Tx) error {
+		after = loadRecord(t, tx.Bucket([]byte(common.ScorerAssetsBucket)), "a-1")
+
+		return nil
+	})
+
+	loser, _, _, _ := scorer.UpdateRatings(before.Rating, before.Games, before.Rating, before.Games)
+	assert.Less(t, after.Rating-before.Rating, loser-before.Rating)
+}
@@ -0,0 +1,31 @@
+package scorer
+
+type HistoryEntry struct {
+	Timestamp int64   `json:"timestamp"`
+	Rating    float64 `json:"rating"`
+	Games     int64   `json:"games"`
+}
+
+type PairRecord struct {
+	OpponentAssetID string `json:"opponent_asset_id"`
+
+	Wins   int64 `json:"wins"`
+	Losses int64 `json:"losses"`
+	Ties   int64 `json:"ties"`
+}
+
+// Ballot is what a voter decided in an outcome, kept for batch models that
+// need more than the aggregated head-to-head records.
+type Ballot struct {
+	VoterID   string `json:"voter_id,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+
+	// Assets are the opponents in the order they were shown.
+	Assets []string `json:"assets"`
+
+	// Winner is the winning asset, empty for a tie.
+	Winner string `json:"winner,omitempty"`
+
+	// WinnerSlot is the position Winner was shown in, -1 for a tie.
+	WinnerSlot int `json:"winner_slot"`
+}
@@ -0,0 +1,84 @@
+package scorer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vreid/shiki/internal/pkg/matchmaker"
+	"github.com/vreid/shiki/internal/pkg/voter"
+	"go.etcd.io/bbolt"
+)
+
+// TimingDiscount is the weight of votes of voters whose timing looks
+// automated, when the timing policy discounts them.
+const TimingDiscount = 0.1
+
+// Options control how outcomes are applied.
+type Options struct {
+	// GoldMinAnswers is how many gold match-ups a voter answers before their
+	// accuracy is judged, 0 disables the policy.
+	GoldMinAnswers  int64
+	GoldMinAccuracy float64
+	GoldAction      GoldAction
+
+	Timing voter.TimingPolicy
+
+	// PositionCorrection removes the measured advantage of the winner's slot
+	// from rating updates.
+	PositionCorrection bool
+}
+
+// Weight returns how much the votes of a voter count, and whether they
+// should be quarantined instead.
+func (o Options) Weight(stats voter.Stats) (float64, bool) {
+	weight := 1.0
+
+	if o.GoldMinAnswers > 0 && stats.GoldAnswers >= o.GoldMinAnswers {
+		accuracy := stats.GoldAccuracy()
+
+		switch {
+		case accuracy >= o.GoldMinAccuracy:
+		case o.GoldAction == GoldActionQuarantine:
+			return 0, true
+		default:
+			weight = accuracy
+		}
+	}
+
+	if o.Timing.Action == voter.TimingActionDiscount && o.Timing.Check(stats) != "" {
+		weight *= TimingDiscount
+	}
+
+	return weight, false
+}
+
+// voterWeight applies the options to the voter of an outcome. Outcomes
+// without a voter or of voters without statistics count fully, unless the
+// matchmaker flagged the timing of their client.
+func voterWeight(tx *bbolt.Tx, outcome matchmaker.Outcome, options Options) (float64, bool, error) {
+	weight := 1.0
+	if outcome.TimingFlagged && options.Timing.Action == voter.TimingActionDiscount {
+		weight = TimingDiscount
+	}
+
+	voterID := outcome.SignedMatchUp.MatchUp.VoterID
+	if voterID == "" || (options.GoldMinAnswers <= 0 && options.Timing.Action != voter.TimingActionDiscount) {
+		return weight, false, nil
+	}
+
+	stats, err := voter.Load(tx, voterID)
+	if errors.Is(err, voter.ErrVoterNotFound) {
+		return weight, false, nil
+	} else if err != nil {
+		return 0, false, fmt.Errorf("failed to load voter: %w", err)
+	}
+
+	if outcome.TimingFlagged {
+		// Don't discount twice for the same timing.
+		stats.RecentTimesMs = nil
+	}
+
+	statsWeight, quarantine := options.Weight(stats)
+
+	return weight * statsWeight, quarantine, nil
+}
@@ -0,0 +1,169 @@
+package scorer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"go.etcd.io/bbolt"
+)
+
+const PairRecordSize = 24
+
+var ErrInvalidPairRecord = errors.New("invalid pair record")
+
+type PairResult int
+
+const (
+	PairWin PairResult = iota
+	PairLoss
+	PairTie
+)
+
+// RecordPair adds the result of assetID against opponentAssetID, from the
+// perspective of assetID, and the mirrored result for the opponent. Every
+// asset has its own nested bucket keyed by opponent asset ID.
+func RecordPair(tx *bbolt.Tx, namespace, assetID, opponentAssetID string, result PairResult) error {
+	pairs, err := createNamespaceBucket(tx, common.ScorerPairsBucket, namespace)
+	if err != nil {
+		return err
+	}
+
+	err = addPairResult(pairs, assetID, opponentAssetID, result)
+	if err != nil {
+		return err
+	}
+
+	mirrored := result
+
+	switch result {
+	case PairWin:
+		mirrored = PairLoss
+	case PairLoss:
+		mirrored = PairWin
+	case PairTie:
+	}
+
+	return addPairResult(pairs, opponentAssetID, assetID, mirrored)
+}
+
+// LoadPairRecords returns the record of an asset against every opponent it
+// has met, ordered by opponent asset ID.
+func LoadPairRecords(tx *bbolt.Tx, namespace, assetID string) ([]PairRecord, error) {
+	result := []PairRecord{}
+
+	pairs := tx.Bucket(common.NamespaceBucket(common.ScorerPairsBucket, namespace))
+	if pairs == nil {
+		return result, nil
+	}
+
+	assetPairs := pairs.Bucket([]byte(assetID))
+	if assetPairs == nil {
+		return result, nil
+	}
+
+	err := assetPairs.ForEach(func(k, v []byte) error {
+		record, err := decodePairRecord(v)
+		if err != nil {
+			return err
+		}
+
+		record.OpponentAssetID = string(k)
+		result = append(result, record)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pairs of %s: %w", assetID, err)
+	}
+
+	return result, nil
+}
+
+// ForEachPairRecord calls f for the stored record of every asset against each
+// of its opponents. Every pair is visited twice, once from either side.
+func ForEachPairRecord(tx *bbolt.Tx, namespace string, f func(assetID string, record PairRecord) error) error {
+	pairs := tx.Bucket(common.NamespaceBucket(common.ScorerPairsBucket, namespace))
+	if pairs == nil {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return pairs.ForEachBucket(func(k []byte) error {
+		assetID := string(k)
+
+		records, err := LoadPairRecords(tx, namespace, assetID)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			err := f(assetID, record)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func addPairResult(pairs *bbolt.Bucket, assetID, opponentAssetID string, result PairResult) error {
+	assetPairs, err := pairs.CreateBucketIfNotExists([]byte(assetID))
+	if err != nil {
+		return fmt.Errorf("failed to create pairs bucket for %s: %w", assetID, err)
+	}
+
+	record := PairRecord{}
+
+	value := assetPairs.Get([]byte(opponentAssetID))
+	if value != nil {
+		record, err = decodePairRecord(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch result {
+	case PairWin:
+		record.Wins++
+	case PairLoss:
+		record.Losses++
+	case PairTie:
+		record.Ties++
+	}
+
+	err = assetPairs.Put([]byte(opponentAssetID), encodePairRecord(record))
+	if err != nil {
+		return fmt.Errorf("failed to put pair record: %w", err)
+	}
+
+	return nil
+}
+
+func encodePairRecord(record PairRecord) []byte {
+	buf := make([]byte, PairRecordSize)
+
+	//nolint:gosec // Intentional conversion for binary encoding
+	binary.LittleEndian.PutUint64(buf[0:8], uint64(record.Wins))
+	//nolint:gosec // Intentional conversion for binary encoding
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(record.Losses))
+	//nolint:gosec // Intentional conversion for binary encoding
+	binary.LittleEndian.PutUint64(buf[16:24], uint64(record.Ties))
+
+	return buf
+}
+
+func decodePairRecord(b []byte) (PairRecord, error) {
+	if len(b) != PairRecordSize {
+		return PairRecord{}, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidPairRecord, PairRecordSize, len(b))
+	}
+
+	//nolint:gosec // Intentional conversion from binary encoding
+	return PairRecord{
+		Wins:   int64(binary.LittleEndian.Uint64(b[0:8])),
+		Losses: int64(binary.LittleEndian.Uint64(b[8:16])),
+		Ties:   int64(binary.LittleEndian.Uint64(b[16:24])),
+	}, nil
+}
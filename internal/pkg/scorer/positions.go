@@ -0,0 +1,98 @@
+package scorer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/vreid/shiki/internal/pkg/common"
+	"go.etcd.io/bbolt"
+)
+
+// PositionMinVotes is how many decisive votes a namespace needs before the
+// position correction kicks in.
+const PositionMinVotes = 100
+
+var ErrPositionsBucketNotFound = errors.New("positions bucket doesn't exist")
+
+// PositionStats counts the decisive votes of match-ups with the same number
+// of opponents by the slot the winner was shown in.
+type PositionStats struct {
+	Votes int64   `json:"votes"`
+	Wins  []int64 `json:"wins"`
+}
+
+// Offsets returns the rating points every slot is worth, from how often it
+// won compared to a fair share. A single win is added to every slot so slots
+// that never won stay finite.
+func (p PositionStats) Offsets() []float64 {
+	n := float64(len(p.Wins))
+	result := make([]float64, len(p.Wins))
+
+	for slot, wins := range p.Wins {
+		share := (float64(wins) + 1.0) / (float64(p.Votes) + n)
+		result[slot] = 400.0 * math.Log10(share*n)
+	}
+
+	return result
+}
+
+func positionKey(namespace string, opponents int) []byte {
+	return []byte(namespace + "|" + strconv.Itoa(opponents))
+}
+
+// LoadPositionStats returns the position statistics of a ratings namespace
+// for match-ups with the given number of opponents.
+func LoadPositionStats(tx *bbolt.Tx, namespace string, opponents int) (PositionStats, error) {
+	result := PositionStats{Wins: make([]int64, opponents)}
+
+	positions := tx.Bucket([]byte(common.ScorerPositionsBucket))
+	if positions == nil {
+		return result, nil
+	}
+
+	value := positions.Get(positionKey(namespace, opponents))
+	if value == nil {
+		return result, nil
+	}
+
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return PositionStats{}, fmt.Errorf("failed to unmarshal position stats: %w", err)
+	}
+
+	for len(result.Wins) < opponents {
+		result.Wins = append(result.Wins, 0)
+	}
+
+	return result, nil
+}
+
+func recordPosition(tx *bbolt.Tx, namespace string, opponents, winnerSlot int) error {
+	positions := tx.Bucket([]byte(common.ScorerPositionsBucket))
+	if positions == nil {
+		return ErrPositionsBucketNotFound
+	}
+
+	stats, err := LoadPositionStats(tx, namespace, opponents)
+	if err != nil {
+		return err
+	}
+
+	stats.Votes++
+	stats.Wins[winnerSlot]++
+
+	value, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal position stats: %w", err)
+	}
+
+	err = positions.Put(positionKey(namespace, opponents), value)
+	if err != nil {
+		return fmt.Errorf("failed to put position stats: %w", err)
+	}
+
+	return nil
+}
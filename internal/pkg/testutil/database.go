@@ -0,0 +1,40 @@
+// Package testutil holds helpers shared by the tests of several packages.
+package testutil
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// OpenDatabase opens an empty database in a temporary directory of the test.
+// It is closed when the test ends.
+func OpenDatabase(tb testing.TB) *bolt.DB {
+	tb.Helper()
+
+	db, err := bolt.Open(filepath.Join(tb.TempDir(), "shiki-test.db"), 0600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(tb, err)
+
+	tb.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+// OpenMigratedDatabase opens a database like OpenDatabase and migrates it to
+// the latest schema.
+func OpenMigratedDatabase(tb testing.TB) *bolt.DB {
+	tb.Helper()
+
+	db := OpenDatabase(tb)
+
+	_, err := common.Migrate(db, false)
+	require.NoError(tb, err)
+
+	return db
+}
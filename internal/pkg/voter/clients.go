@@ -0,0 +1,78 @@
+package voter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ClientIdleTimeout is how long the timing of a client is kept after its
+// last vote.
+const ClientIdleTimeout = time.Hour
+
+// ClientTiming keeps the recent vote times of anonymous clients in memory.
+// Anonymous voters without a cookie get a new voter ID with every request,
+// so their timing is tracked per client instead.
+type ClientTiming struct {
+	mu        sync.Mutex
+	clients   map[string]*clientTimes
+	lastSweep time.Time
+}
+
+type clientTimes struct {
+	timesMs  []int64
+	lastVote time.Time
+}
+
+func NewClientTiming() *ClientTiming {
+	return &ClientTiming{clients: map[string]*clientTimes{}}
+}
+
+// ClientID returns who the timing of an anonymous request is tracked
+// against.
+func ClientID(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+// Record adds the time of a vote to the timing of a client and returns its
+// recent times as Stats, to be judged by a TimingPolicy.
+func (t *ClientTiming) Record(clientID string, durationMs int64, now time.Time) Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+
+	client, ok := t.clients[clientID]
+	if !ok {
+		client = &clientTimes{}
+		t.clients[clientID] = client
+	}
+
+	client.lastVote = now
+
+	if durationMs >= 0 {
+		client.timesMs = append(client.timesMs, durationMs)
+		if len(client.timesMs) > RecentVotes {
+			client.timesMs = client.timesMs[len(client.timesMs)-RecentVotes:]
+		}
+	}
+
+	return Stats{VoterID: clientID, RecentTimesMs: append([]int64{}, client.timesMs...)}
+}
+
+// sweep forgets clients that haven't voted for ClientIdleTimeout, at most
+// once per ClientIdleTimeout.
+func (t *ClientTiming) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < ClientIdleTimeout {
+		return
+	}
+
+	for clientID, client := range t.clients {
+		if now.Sub(client.lastVote) >= ClientIdleTimeout {
+			delete(t.clients, clientID)
+		}
+	}
+
+	t.lastSweep = now
+}
@@ -0,0 +1,276 @@
+package voter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/oidc"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Voter IDs are prefixed with where they come from.
+const (
+	UserPrefix      = "user:"
+	KeyPrefix       = "key:"
+	AnonymousPrefix = "anon:"
+)
+
+const (
+	Cookie = "shiki_voter"
+
+	// CookieMaxAge is how long an anonymous voter keeps its ID.
+	CookieMaxAge = 365 * 24 * time.Hour
+
+	contextKey = "voter-id"
+)
+
+var (
+	ErrVoterNotFound        = errors.New("voter not found")
+	ErrVotersBucketNotFound = errors.New("voters bucket doesn't exist")
+)
+
+// VoterService gives every request a voter ID: the logged in user, the API
+// key, or an anonymous ID kept in a signed cookie.
+type VoterService struct {
+	CookieSecret []byte
+
+	Timing TimingPolicy
+
+	// Clients is the timing of anonymous voters per client.
+	Clients *ClientTiming
+}
+
+func NewVoterService(i do.Injector) (*VoterService, error) {
+	signatureSecret := do.MustInvokeNamed[string](i, "signature-secret")
+
+	timingAction, err := ParseTimingAction(do.MustInvokeNamed[string](i, "timing-action"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Identify reads what these services' middlewares put in the context,
+	// so they have to be registered first.
+	do.MustInvoke[*auth.AuthService](i)
+	do.MustInvoke[*oidc.OIDCService](i)
+
+	result := &VoterService{
+		CookieSecret: common.DeriveKey(signatureSecret, "voter-cookie"),
+
+		Timing: TimingPolicy{
+			MinMeanMs: int64(do.MustInvokeNamed[int](i, "timing-min-mean-ms")),
+			MinCV:     do.MustInvokeNamed[float64](i, "timing-min-cv"),
+			Action:    timingAction,
+		},
+
+		Clients: NewClientTiming(),
+	}
+
+	echoService, err := do.Invoke[*common.EchoService](i)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create echo service: %w", err)
+	}
+
+	echoService.Register(func(e *echo.Echo) {
+		e.Use(result.Identify())
+	})
+
+	return result, nil
+}
+
+// Authenticated reports whether a voter ID belongs to a logged in user or an
+// API key rather than an anonymous cookie.
+func Authenticated(voterID string) bool {
+	return strings.HasPrefix(voterID, UserPrefix) || strings.HasPrefix(voterID, KeyPrefix)
+}
+
+// FromContext returns the voter ID of a request.
+func FromContext(c echo.Context) string {
+	voterID, _ := c.Get(contextKey).(string)
+
+	return voterID
+}
+
+func (s *VoterService) sign(id string) string {
+	h := hmac.New(sha256.New, s.CookieSecret)
+	h.Write([]byte(id))
+
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *VoterService) anonymousID(c echo.Context) string {
+	cookie, err := c.Cookie(Cookie)
+	if err == nil {
+		id, signature, ok := strings.Cut(cookie.Value, ".")
+		if ok && hmac.Equal([]byte(signature), []byte(s.sign(id))) {
+			return id
+		}
+	}
+
+	id := uuid.NewString()
+
+	c.SetCookie(&http.Cookie{
+		Name:     Cookie,
+		Value:    id + "." + s.sign(id),
+		Path:     "/",
+		MaxAge:   int(CookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return id
+}
+
+// Identify stores the voter ID of a request in the context and hands out
+// anonymous IDs to new voters.
+func (s *VoterService) Identify() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var voterID string
+
+			if session, ok := oidc.UserFromContext(c); ok {
+				voterID = UserPrefix + session.UserID
+			} else if key, ok := auth.KeyFromContext(c); ok {
+				voterID = KeyPrefix + key.ID
+			} else {
+				voterID = AnonymousPrefix + s.anonymousID(c)
+			}
+
+			c.Set(contextKey, voterID)
+
+			return next(c)
+		}
+	}
+}
+
+// Record adds a vote to the statistics of its voter.
+func Record(tx *bolt.Tx, vote Vote) error {
+	voters := tx.Bucket([]byte(common.ScorerVotersBucket))
+	if voters == nil {
+		return ErrVotersBucketNotFound
+	}
+
+	stats, err := load(voters, vote.VoterID)
+	if errors.Is(err, ErrVoterNotFound) {
+		stats = Stats{VoterID: vote.VoterID, PositionWins: []int64{}, FirstVote: vote.Timestamp}
+	} else if err != nil {
+		return err
+	}
+
+	stats.Votes++
+	stats.LastVote = vote.Timestamp
+
+	if vote.DurationMs >= 0 {
+		stats.TimedVotes++
+		stats.TotalTimeMs += vote.DurationMs
+
+		stats.RecentTimesMs = append(stats.RecentTimesMs, vote.DurationMs)
+		if len(stats.RecentTimesMs) > RecentVotes {
+			stats.RecentTimesMs = stats.RecentTimesMs[len(stats.RecentTimesMs)-RecentVotes:]
+		}
+	}
+
+	switch vote.Consensus {
+	case 1:
+		stats.Agreements++
+	case -1:
+		stats.Disagreements++
+	}
+
+	switch vote.Gold {
+	case 1:
+		stats.GoldAnswers++
+		stats.GoldCorrect++
+	case -1:
+		stats.GoldAnswers++
+	}
+
+	if vote.WinnerSlot < 0 {
+		stats.Ties++
+	} else {
+		for len(stats.PositionWins) <= vote.WinnerSlot {
+			stats.PositionWins = append(stats.PositionWins, 0)
+		}
+
+		stats.PositionWins[vote.WinnerSlot]++
+
+		if vote.Opponents > 0 {
+			stats.ExpectedFirstWins += 1.0 / float64(vote.Opponents)
+		}
+	}
+
+	value, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal voter stats: %w", err)
+	}
+
+	err = voters.Put([]byte(vote.VoterID), value)
+	if err != nil {
+		return fmt.Errorf("failed to put voter stats: %w", err)
+	}
+
+	return nil
+}
+
+func load(voters *bolt.Bucket, voterID string) (Stats, error) {
+	value := voters.Get([]byte(voterID))
+	if value == nil {
+		return Stats{}, fmt.Errorf("%w: %s", ErrVoterNotFound, voterID)
+	}
+
+	var result Stats
+
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to unmarshal voter stats %s: %w", voterID, err)
+	}
+
+	return result, nil
+}
+
+// Load reads the statistics of a voter.
+func Load(tx *bolt.Tx, voterID string) (Stats, error) {
+	voters := tx.Bucket([]byte(common.ScorerVotersBucket))
+	if voters == nil {
+		return Stats{}, ErrVotersBucketNotFound
+	}
+
+	return load(voters, voterID)
+}
+
+// List reads the statistics of every voter, ordered by voter ID.
+func List(tx *bolt.Tx) ([]Stats, error) {
+	voters := tx.Bucket([]byte(common.ScorerVotersBucket))
+	if voters == nil {
+		return nil, ErrVotersBucketNotFound
+	}
+
+	result := []Stats{}
+
+	err := voters.ForEach(func(k, _ []byte) error {
+		stats, err := load(voters, string(k))
+		if err != nil {
+			return err
+		}
+
+		result = append(result, stats)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voters: %w", err)
+	}
+
+	return result, nil
+}
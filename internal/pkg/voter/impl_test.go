@@ -0,0 +1,160 @@
+package voter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vreid/shiki/internal/pkg/common"
+	voter "github.com/vreid/shiki/internal/pkg/voter"
+)
+
+func TestIdentify(t *testing.T) {
+	t.Parallel()
+
+	service := &voter.VoterService{CookieSecret: common.DeriveKey("secret", "voter-cookie")}
+
+	e := echo.New()
+	e.Use(service.Identify())
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, voter.FromContext(c))
+	})
+
+	serve := func(cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve()
+	voterID := rec.Body.String()
+
+	require.True(t, strings.HasPrefix(voterID, voter.AnonymousPrefix))
+	assert.False(t, voter.Authenticated(voterID))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	rec = serve(cookies...)
+	assert.Equal(t, voterID, rec.Body.String())
+	assert.Empty(t, rec.Result().Cookies())
+
+	cookies[0].Value = "forged" + cookies[0].Value[strings.Index(cookies[0].Value, "."):]
+
+	rec = serve(cookies...)
+	assert.NotEqual(t, voterID, rec.Body.String())
+}
+
+func TestSummary(t *testing.T) {
+	t.Parallel()
+
+	stats := voter.Stats{
+		Votes:             5,
+		Ties:              1,
+		TimedVotes:        4,
+		TotalTimeMs:       10000,
+		Agreements:        3,
+		Disagreements:     1,
+		PositionWins:      []int64{3, 1},
+		ExpectedFirstWins: 2,
+		GoldAnswers:       4,
+		GoldCorrect:       3,
+	}
+
+	summary := stats.Summary()
+
+	assert.InDelta(t, 2500.0, summary.MeanTimeMs, 0.0001)
+	assert.InDelta(t, 0.75, summary.AgreementRate, 0.0001)
+	assert.InDelta(t, 0.25, summary.FirstPositionBias, 0.0001)
+	assert.InDelta(t, 0.75, summary.GoldAccuracy, 0.0001)
+}
+
+func TestTimingPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := voter.TimingPolicy{MinMeanMs: 1000, MinCV: 0.1, Action: voter.TimingActionReject}
+
+	regular := voter.Stats{RecentTimesMs: make([]int64, voter.RecentVotes)}
+	for idx := range regular.RecentTimesMs {
+		regular.RecentTimesMs[idx] = 3000
+	}
+
+	assert.Contains(t, policy.Check(regular), "coefficient of variation")
+	assert.Empty(t, policy.Check(voter.Stats{RecentTimesMs: regular.RecentTimesMs[1:]}))
+
+	varied := voter.Stats{RecentTimesMs: make([]int64, voter.RecentVotes)}
+	for idx := range varied.RecentTimesMs {
+		varied.RecentTimesMs[idx] = int64(2000 + 500*(idx%4))
+	}
+
+	assert.Empty(t, policy.Check(varied))
+
+	for idx := range varied.RecentTimesMs {
+		varied.RecentTimesMs[idx] /= 10
+	}
+
+	assert.Contains(t, policy.Check(varied), "mean time")
+
+	_, err := voter.ParseTimingAction("ignore")
+	require.ErrorIs(t, err, voter.ErrUnknownTimingAction)
+}
+
+func TestClientTiming(t *testing.T) {
+	t.Parallel()
+
+	service := &voter.VoterService{
+		CookieSecret: common.DeriveKey("secret", "voter-cookie"),
+		Timing:       voter.TimingPolicy{MinMeanMs: 1000, Action: voter.TimingActionReject},
+		Clients:      voter.NewClientTiming(),
+	}
+
+	e := echo.New()
+	e.Use(service.Identify())
+	e.POST("/", func(c echo.Context) error {
+		stats := service.Clients.Record(voter.ClientID(c), 50, time.Now())
+
+		return c.String(http.StatusOK, voter.FromContext(c)+"|"+service.Timing.Check(stats))
+	})
+
+	serve := func(remoteAddr string) (string, string) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		voterID, reason, _ := strings.Cut(rec.Body.String(), "|")
+
+		return voterID, reason
+	}
+
+	// A script without cookies is a new voter with every request, but its
+	// timing still adds up per client.
+	voterIDs := map[string]bool{}
+
+	for range voter.RecentVotes - 1 {
+		voterID, reason := serve("192.0.2.1:1234")
+		assert.Empty(t, reason)
+
+		voterIDs[voterID] = true
+	}
+
+	assert.Len(t, voterIDs, voter.RecentVotes-1)
+
+	_, reason := serve("192.0.2.1:1234")
+	assert.Contains(t, reason, "mean time of 50 ms")
+
+	_, reason = serve("192.0.2.2:1234")
+	assert.Empty(t, reason)
+}
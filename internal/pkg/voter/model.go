@@ -0,0 +1,110 @@
+package voter
+
+// Vote is what the per-voter statistics learn from one outcome.
+type Vote struct {
+	VoterID   string
+	Timestamp int64
+
+	// DurationMs is the time between issuing the match-up and receiving the
+	// outcome, or -1 when unknown.
+	DurationMs int64
+
+	// WinnerSlot is the position of the winner in the match-up, or -1 for a
+	// tie.
+	WinnerSlot int
+	Opponents  int
+
+	// Consensus is 1 if the winner was the favourite by rating, -1 if
+	// another opponent was and 0 when there was no clear favourite.
+	Consensus int
+
+	// Gold is 1 for a correct answer to a gold match-up, -1 for a wrong one
+	// and 0 when the match-up wasn't gold.
+	Gold int
+}
+
+type Stats struct {
+	VoterID string `json:"voter_id"`
+
+	Votes int64 `json:"votes"`
+	Ties  int64 `json:"ties"`
+
+	TimedVotes  int64 `json:"timed_votes"`
+	TotalTimeMs int64 `json:"total_time_ms"`
+
+	// RecentTimesMs are the times of the last RecentVotes timed votes.
+	RecentTimesMs []int64 `json:"recent_times_ms,omitempty"`
+
+	Agreements    int64 `json:"agreements"`
+	Disagreements int64 `json:"disagreements"`
+
+	// PositionWins counts the decisive votes per winning slot.
+	PositionWins []int64 `json:"position_wins"`
+
+	// ExpectedFirstWins is the number of decisive votes the first slot
+	// would have won without a position bias.
+	ExpectedFirstWins float64 `json:"expected_first_wins"`
+
+	GoldAnswers int64 `json:"gold_answers"`
+	GoldCorrect int64 `json:"gold_correct"`
+
+	FirstVote int64 `json:"first_vote"`
+	LastVote  int64 `json:"last_vote"`
+}
+
+// Summary is Stats with the derived rates.
+type Summary struct {
+	Stats
+
+	MeanTimeMs float64 `json:"mean_time_ms"`
+
+	RecentMeanTimeMs float64 `json:"recent_mean_time_ms"`
+
+	// RecentTimeCV is the coefficient of variation of the recent vote times.
+	RecentTimeCV float64 `json:"recent_time_cv"`
+
+	// AgreementRate is the share of votes with a clear favourite that went
+	// to the favourite.
+	AgreementRate float64 `json:"agreement_rate"`
+
+	// FirstPositionBias is how much more often the first slot won than
+	// expected, as a share of the decisive votes.
+	FirstPositionBias float64 `json:"first_position_bias"`
+
+	// GoldAccuracy is the share of gold match-ups answered correctly.
+	GoldAccuracy float64 `json:"gold_accuracy"`
+}
+
+func (s Stats) Summary() Summary {
+	result := Summary{Stats: s}
+
+	if s.TimedVotes > 0 {
+		result.MeanTimeMs = float64(s.TotalTimeMs) / float64(s.TimedVotes)
+	}
+
+	result.RecentMeanTimeMs, result.RecentTimeCV = recentTiming(s.RecentTimesMs)
+
+	if judged := s.Agreements + s.Disagreements; judged > 0 {
+		result.AgreementRate = float64(s.Agreements) / float64(judged)
+	}
+
+	if decisive := s.Votes - s.Ties; decisive > 0 && len(s.PositionWins) > 0 {
+		result.FirstPositionBias = (float64(s.PositionWins[0]) - s.ExpectedFirstWins) / float64(decisive)
+	}
+
+	if s.GoldAnswers > 0 {
+		result.GoldAccuracy = s.GoldAccuracy()
+	}
+
+	return result
+}
+
+// GoldAccuracy returns the share of gold match-ups answered correctly, or 1
+// if the voter hasn't answered any.
+func (s Stats) GoldAccuracy() float64 {
+	if s.GoldAnswers == 0 {
+		return 1
+	}
+
+	return float64(s.GoldCorrect) / float64(s.GoldAnswers)
+}
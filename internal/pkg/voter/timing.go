@@ -0,0 +1,86 @@
+package voter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// RecentVotes is how many of a voter's latest timed votes their timing is
+// judged on.
+const RecentVotes = 20
+
+// TimingAction is what happens to the votes of voters whose timing looks
+// automated.
+type TimingAction string
+
+const (
+	TimingActionReject   TimingAction = "reject"
+	TimingActionDiscount TimingAction = "discount"
+	TimingActionLog      TimingAction = "log"
+)
+
+var ErrUnknownTimingAction = errors.New("unknown timing action")
+
+func ParseTimingAction(value string) (TimingAction, error) {
+	switch action := TimingAction(value); action {
+	case TimingActionReject, TimingActionDiscount, TimingActionLog:
+		return action, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownTimingAction, value)
+	}
+}
+
+// TimingPolicy flags voters whose recent votes came too fast or too
+// regularly for a human. Zero thresholds are disabled.
+type TimingPolicy struct {
+	MinMeanMs int64
+	MinCV     float64
+	Action    TimingAction
+}
+
+// Check returns why the timing of a voter looks automated, or "" if it
+// doesn't or there aren't RecentVotes timed votes yet.
+func (p TimingPolicy) Check(s Stats) string {
+	if len(s.RecentTimesMs) < RecentVotes {
+		return ""
+	}
+
+	mean, cv := recentTiming(s.RecentTimesMs)
+
+	switch {
+	case p.MinMeanMs > 0 && mean < float64(p.MinMeanMs):
+		return fmt.Sprintf("mean time of %.0f ms over the last %d votes", mean, len(s.RecentTimesMs))
+	case p.MinCV > 0 && cv < p.MinCV:
+		return fmt.Sprintf("coefficient of variation of %.3f over the last %d votes", cv, len(s.RecentTimesMs))
+	default:
+		return ""
+	}
+}
+
+// recentTiming returns the mean and the coefficient of variation of the
+// recent vote times.
+func recentTiming(timesMs []int64) (float64, float64) {
+	if len(timesMs) == 0 {
+		return 0, 0
+	}
+
+	sum := 0.0
+	for _, t := range timesMs {
+		sum += float64(t)
+	}
+
+	mean := sum / float64(len(timesMs))
+	if mean == 0 {
+		return 0, 0
+	}
+
+	variance := 0.0
+
+	for _, t := range timesMs {
+		d := float64(t) - mean
+		variance += d * d
+	}
+
+	return mean, math.Sqrt(variance/float64(len(timesMs))) / mean
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vreid/shiki/internal/pkg/admin"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+func createKey(ctx context.Context, cmd *cli.Command) error {
+	scopes, err := auth.ParseScopes(cmd.StringSlice("scope"))
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	var result admin.CreatedKey
+
+	if len(cmd.String("server")) > 0 {
+		request := admin.KeyRequest{Name: cmd.String("name"), Scopes: cmd.StringSlice("scope")}
+
+		err = adminRequest(ctx, cmd, http.MethodPost, "/keys", request, &result)
+		if err != nil {
+			return fmt.Errorf("failed to create API key: %w", err)
+		}
+	} else {
+		err = withDatabase(cmd, func(dbService *common.DatabaseService) error {
+			return dbService.DB.Update(func(tx *bolt.Tx) error {
+				var err error
+
+				result.Key, result.Token, err = auth.Create(tx, cmd.String("name"), scopes)
+
+				return err
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create API key: %w", err)
+		}
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Created API key %s, the token is only shown once:\n", result.Key.ID)
+	_, _ = fmt.Fprintln(os.Stdout, result.Token)
+
+	return nil
+}
+
+func listKeys(ctx context.Context, cmd *cli.Command) error {
+	var (
+		keys []auth.Key
+		err  error
+	)
+
+	if len(cmd.String("server")) > 0 {
+		err = adminRequest(ctx, cmd, http.MethodGet, "/keys", nil, &keys)
+	} else {
+		err = withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+			return dbService.DB.View(func(tx *bolt.Tx) error {
+				var err error
+
+				keys, err = auth.List(tx)
+
+				return err
+			})
+		})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	switch cmd.String("format") {
+	case "table":
+		if len(keys) == 0 {
+			_, _ = fmt.Fprintln(os.Stdout, "No API keys found")
+
+			return nil
+		}
+
+		_, _ = fmt.Fprintln(os.Stdout, "ID\t\t\tCreated\t\t\tRevoked\t\t\tScopes\t\tName")
+		_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+		for _, key := range keys {
+			scopes := make([]string, 0, len(key.Scopes))
+			for _, scope := range key.Scopes {
+				scopes = append(scopes, string(scope))
+			}
+
+			revoked := "-\t\t\t"
+			if key.Revoked() {
+				revoked = key.RevokedAt.Format(time.RFC3339)
+			}
+
+			_, _ = fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\t\t%s\n",
+				key.ID, key.CreatedAt.Format(time.RFC3339), revoked, strings.Join(scopes, ","), key.Name)
+		}
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(keys)
+		if err != nil {
+			return fmt.Errorf("failed to encode API keys: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+	}
+
+	return nil
+}
+
+func revokeKey(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return errMissingKeyID
+	}
+
+	var (
+		key auth.Key
+		err error
+	)
+
+	if len(cmd.String("server")) > 0 {
+		err = adminRequest(ctx, cmd, http.MethodDelete, "/keys/"+url.PathEscape(cmd.Args().First()), nil, &key)
+	} else {
+		err = withDatabase(cmd, func(dbService *common.DatabaseService) error {
+			return dbService.DB.Update(func(tx *bolt.Tx) error {
+				var err error
+
+				key, err = auth.Revoke(tx, cmd.Args().First())
+
+				return err
+			})
+		})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Revoked API key %s (%s)\n", key.ID, key.Name)
+
+	return nil
+}
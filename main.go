@@ -1,57 +1,123 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
 
 	"github.com/samber/do/v2"
+	"github.com/vreid/shiki/internal/pkg/admin"
+	"github.com/vreid/shiki/internal/pkg/auth"
+	"github.com/vreid/shiki/internal/pkg/backup"
 	"github.com/vreid/shiki/internal/pkg/common"
+	"github.com/vreid/shiki/internal/pkg/contest"
+	"github.com/vreid/shiki/internal/pkg/integrity"
 	"github.com/vreid/shiki/internal/pkg/matchmaker"
+	"github.com/vreid/shiki/internal/pkg/oidc"
+	"github.com/vreid/shiki/internal/pkg/ratelimit"
+	"github.com/vreid/shiki/internal/pkg/ratings"
 	"github.com/vreid/shiki/internal/pkg/receiver"
 	"github.com/vreid/shiki/internal/pkg/scorer"
+	"github.com/vreid/shiki/internal/pkg/voter"
 	bolt "go.etcd.io/bbolt"
 
 	"github.com/urfave/cli/v3"
 )
 
+var (
+	errMissingAssetID = errors.New("at least one asset ID is required")
+	errUnknownFormat  = ratings.ErrUnknownFormat
+	errUnknownSort    = errors.New("unknown sort order")
+
+	errMissingContestID = errors.New("exactly one contest ID is required")
+	errMissingKeyID     = errors.New("exactly one key ID is required")
+	errMissingGoldID    = errors.New("exactly one gold pair ID is required")
+)
+
 type ShikiService struct {
-	EchoService *common.EchoService `do:""`
+	EchoService     *common.EchoService     `do:""`
+	SnapshotService *common.SnapshotService `do:""`
+	AuthService     *auth.AuthService       `do:""`
+	OIDCService     *oidc.OIDCService       `do:""`
+	VoterService    *voter.VoterService     `do:""`
+	AdminService    *admin.AdminService     `do:""`
 
+	ContestService    *contest.ContestService       `do:""`
 	ReceiverService   *receiver.ReceiverService     `do:""`
 	MatchmakerService *matchmaker.MatchmakerService `do:""`
 	ScorerService     *scorer.ScorerService         `do:""`
+	RatingsService    *ratings.RatingsService       `do:""`
 }
 
-func runServer(_ context.Context, cmd *cli.Command) error {
+func runServer(ctx context.Context, cmd *cli.Command) error {
 	i := do.New()
 
 	do.ProvideNamedValue(i, "port", cmd.Int("port"))
 	do.ProvideNamedValue(i, "data-dir", cmd.String("data-dir"))
 	do.ProvideNamedValue(i, "tmp-dir", cmd.String("tmp-dir"))
+	do.ProvideNamedValue(i, "trusted-proxies", cmd.StringSlice("trusted-proxies"))
 
 	do.ProvideNamedValue(i, "signature-secret", cmd.String("signature-secret"))
+	do.ProvideNamedValue(i, "admin-token", cmd.String("admin-token"))
+	do.ProvideNamedValue(i, "anonymous-voting", cmd.Bool("anonymous-voting"))
+	do.ProvideNamedValue(i, "public-ratings", cmd.Bool("public-ratings"))
+
+	do.ProvideNamedValue(i, "oidc-issuer", cmd.String("oidc-issuer"))
+	do.ProvideNamedValue(i, "oidc-client-id", cmd.String("oidc-client-id"))
+	do.ProvideNamedValue(i, "oidc-client-secret", cmd.String("oidc-client-secret"))
+	do.ProvideNamedValue(i, "oidc-redirect-url", cmd.String("oidc-redirect-url"))
 	do.ProvideNamedValue(i, "base-difficulty", cmd.Int("base-difficulty"))
 	do.ProvideNamedValue(i, "opponents", cmd.Int("opponents"))
+	do.ProvideNamedValue(i, "criteria", cmd.StringSlice("criteria"))
 	do.ProvideNamedValue(i, "token-max-age-minutes", cmd.Int("token-max-age-minutes"))
 
-	outcomeChan := make(chan matchmaker.Outcome, 1000)
+	do.ProvideNamedValue(i, "gold-rate", cmd.Float64("gold-rate"))
+	do.ProvideNamedValue(i, "gold-min-answers", cmd.Int("gold-min-answers"))
+	do.ProvideNamedValue(i, "gold-min-accuracy", cmd.Float64("gold-min-accuracy"))
+	do.ProvideNamedValue(i, "gold-action", cmd.String("gold-action"))
+
+	do.ProvideNamedValue(i, "rate-limit-config", cmd.String("rate-limit-config"))
+	do.ProvideNamedValue(i, "rate-limit-match-up", cmd.String("rate-limit-match-up"))
+	do.ProvideNamedValue(i, "rate-limit-outcome", cmd.String("rate-limit-outcome"))
+	do.ProvideNamedValue(i, "rate-limit-upload", cmd.String("rate-limit-upload"))
+
+	do.ProvideNamedValue(i, "position-correction", cmd.Bool("position-correction"))
+
+	do.ProvideNamedValue(i, "min-deliberation-ms", cmd.Int("min-deliberation-ms"))
+	do.ProvideNamedValue(i, "timing-min-mean-ms", cmd.Int("timing-min-mean-ms"))
+	do.ProvideNamedValue(i, "timing-min-cv", cmd.Float64("timing-min-cv"))
+	do.ProvideNamedValue(i, "timing-action", cmd.String("timing-action"))
 
-	var (
-		outcomeSource <-chan matchmaker.Outcome = outcomeChan
-		outcomeSink   chan<- matchmaker.Outcome = outcomeChan
-	)
+	do.ProvideNamedValue(i, "scorer-batch-max-size", cmd.Int("scorer-batch-max-size"))
+	do.ProvideNamedValue(i, "scorer-batch-max-latency-ms", cmd.Int("scorer-batch-max-latency-ms"))
 
-	do.ProvideNamedValue(i, "outcome-source", outcomeSource)
-	do.ProvideNamedValue(i, "outcome-sink", outcomeSink)
+	do.ProvideNamedValue(i, "queue-capacity", cmd.Int("queue-capacity"))
+	do.ProvideNamedValue(i, "snapshot-interval-seconds", cmd.Int("snapshot-interval-seconds"))
 
 	do.Provide(i, common.NewDatabaseService)
 	do.Provide(i, common.NewEchoService)
+	do.Provide(i, common.NewSnapshotService)
 
+	do.Provide(i, matchmaker.NewOutcomeQueue)
+
+	do.Provide(i, auth.NewAuthService)
+	do.Provide(i, oidc.NewOIDCService)
+	do.Provide(i, voter.NewVoterService)
+	do.Provide(i, contest.NewContestService)
+	do.Provide(i, ratelimit.NewRateLimitService)
 	do.Provide(i, receiver.NewReceiverService)
 	do.Provide(i, matchmaker.NewMatchmakerService)
 	do.Provide(i, scorer.NewScorerService)
+	do.Provide(i, ratings.NewRatingsService)
+	do.Provide(i, admin.NewAdminService)
 
 	do.Provide(i, do.InvokeStruct[ShikiService])
 
@@ -61,16 +127,66 @@ func runServer(_ context.Context, cmd *cli.Command) error {
 	}
 
 	shikiService.ScorerService.Start()
+	shikiService.SnapshotService.Start()
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	startErr := make(chan error, 1)
+
+	go func() {
+		startErr <- shikiService.EchoService.Start()
+	}()
+
+	errs := []error{}
 
-	//nolint:wrapcheck
-	return shikiService.EchoService.Start()
+	select {
+	case <-ctx.Done():
+		log.Printf("shutting down")
+	case err = <-startErr:
+		errs = append(errs, err)
+	}
+
+	timeout := time.Duration(cmd.Int("shutdown-timeout-seconds")) * time.Second
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Stop accepting requests and drain in-flight handlers before the scorer
+	// drains the queue, so no outcome is enqueued after the scorer is gone.
+	err = do.ShutdownWithContext[*common.EchoService](shutdownCtx, i)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to shutdown echo service: %w", err))
+	}
+
+	report := i.ShutdownWithContext(shutdownCtx)
+	if !report.Succeed {
+		errs = append(errs, fmt.Errorf("failed to shutdown services: %w", report))
+	}
+
+	return errors.Join(errs...)
 }
 
-func listRatings(_ context.Context, cmd *cli.Command) error {
+func withDatabase(cmd *cli.Command, f func(dbService *common.DatabaseService) error) error {
+	return openDatabase(cmd, common.NewDatabaseService, f)
+}
+
+// withReadOnlyDatabase opens the database for reading. The server holds the
+// database open for writing, so while it runs this reads its latest snapshot
+// instead, which is up to --snapshot-interval-seconds old, and fails if the
+// server doesn't write snapshots.
+func withReadOnlyDatabase(cmd *cli.Command, f func(dbService *common.DatabaseService) error) error {
+	return openDatabase(cmd, common.NewReadOnlyDatabaseService, f)
+}
+
+func openDatabase(
+	cmd *cli.Command,
+	provider do.Provider[*common.DatabaseService],
+	f func(dbService *common.DatabaseService) error) error {
 	i := do.New()
 
 	do.ProvideNamedValue(i, "data-dir", cmd.String("data-dir"))
-	do.Provide(i, common.NewDatabaseService)
+	do.Provide(i, provider)
 
 	dbService, err := do.Invoke[*common.DatabaseService](i)
 	if err != nil {
@@ -84,30 +200,192 @@ func listRatings(_ context.Context, cmd *cli.Command) error {
 		}
 	}()
 
-	err = dbService.DB.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(common.ScorerRatingsBucket))
-		if bucket == nil {
+	return f(dbService)
+}
+
+//nolint:cyclop // Sorting, filtering and formatting options require this complexity
+func listRatings(_ context.Context, cmd *cli.Command) error {
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var result []ratings.Rating
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			result, err = ratings.LoadRatings(tx, namespace)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list ratings: %w", err)
+		}
+
+		result = ratings.FilterMinGames(result, cmd.Int64("min-games"))
+
+		switch cmd.String("sort") {
+		case "rating":
+		case "games":
+			slices.SortStableFunc(result, func(a, b ratings.Rating) int {
+				return cmp.Compare(b.Games, a.Games)
+			})
+		default:
+			return fmt.Errorf("%w: %s", errUnknownSort, cmd.String("sort"))
+		}
+
+		top := cmd.Int("top")
+		if top > 0 && top < len(result) {
+			result = result[:top]
+		}
+
+		format := cmd.String("format")
+		if format != "table" {
+			err = ratings.WriteRatings(os.Stdout, format, result)
+			if err != nil {
+				return fmt.Errorf("failed to write ratings: %w", err)
+			}
+
+			return nil
+		}
+
+		if len(result) == 0 {
 			_, _ = fmt.Fprintln(os.Stdout, "No ratings found")
 
 			return nil
 		}
 
-		_, _ = fmt.Fprintln(os.Stdout, "Asset ID\t\t\t\t\tRating")
+		_, _ = fmt.Fprintln(os.Stdout, "Rank\tAsset ID\t\t\t\t\tRating\tGames")
 		_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
 
-		return bucket.ForEach(func(k, v []byte) error {
-			assetID := string(k)
-			rating := common.BytesToFloat64(v, scorer.DefaultRating)
-			_, _ = fmt.Fprintf(os.Stdout, "%s\t%.2f\n", assetID, rating)
+		for _, rating := range result {
+			_, _ = fmt.Fprintf(os.Stdout, "%d\t%s\t%.2f\t%d\n", rating.Rank, rating.AssetID, rating.Rating, rating.Games)
+		}
+
+		return nil
+	})
+}
+
+func ratingHistory(_ context.Context, cmd *cli.Command) error {
+	assetIDs := cmd.Args().Slice()
+	if len(assetIDs) == 0 {
+		return errMissingAssetID
+	}
+
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			for _, assetID := range assetIDs {
+				history, err := scorer.LoadHistory(tx, namespace, assetID)
+				if err != nil {
+					return fmt.Errorf("failed to load history: %w", err)
+				}
+
+				history = ratings.Downsample(history, cmd.Int64("interval"), cmd.Int("max-points"))
+
+				_, _ = fmt.Fprintf(os.Stdout, "%s\n", assetID)
+				_, _ = fmt.Fprintln(os.Stdout, "Timestamp\t\t\tRating\tGames")
+				_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+				for _, entry := range history {
+					timestamp := time.Unix(entry.Timestamp, 0).UTC().Format(time.RFC3339)
+					_, _ = fmt.Fprintf(os.Stdout, "%s\t%.2f\t%d\n", timestamp, entry.Rating, entry.Games)
+				}
+
+				_, _ = fmt.Fprintln(os.Stdout)
+			}
 
 			return nil
 		})
+		if err != nil {
+			return fmt.Errorf("failed to show rating history: %w", err)
+		}
+
+		return nil
 	})
-	if err != nil {
-		return fmt.Errorf("failed to list ratings: %w", err)
+}
+
+func headToHead(_ context.Context, cmd *cli.Command) error {
+	assetIDs := cmd.Args().Slice()
+	if len(assetIDs) == 0 {
+		return errMissingAssetID
 	}
 
-	return nil
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			for _, assetID := range assetIDs {
+				records, err := scorer.LoadPairRecords(tx, namespace, assetID)
+				if err != nil {
+					return fmt.Errorf("failed to load head-to-head records: %w", err)
+				}
+
+				_, _ = fmt.Fprintf(os.Stdout, "%s\n", assetID)
+				_, _ = fmt.Fprintln(os.Stdout, "Opponent Asset ID\t\t\t\tWins\tLosses\tTies")
+				_, _ = fmt.Fprintln(os.Stdout, "-----------------------------------------------------------")
+
+				for _, record := range records {
+					_, _ = fmt.Fprintf(os.Stdout, "%s\t%d\t%d\t%d\n",
+						record.OpponentAssetID, record.Wins, record.Losses, record.Ties)
+				}
+
+				_, _ = fmt.Fprintln(os.Stdout)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to show head-to-head records: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func exportMatrix(_ context.Context, cmd *cli.Command) error {
+	return withReadOnlyDatabase(cmd, func(dbService *common.DatabaseService) error {
+		var matrix ratings.Matrix
+
+		err := dbService.DB.View(func(tx *bolt.Tx) error {
+			namespace, err := loadNamespace(tx, cmd)
+			if err != nil {
+				return err
+			}
+
+			matrix, err = ratings.LoadMatrix(tx, namespace, cmd.Int("top"))
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load head-to-head matrix: %w", err)
+		}
+
+		switch cmd.String("format") {
+		case "csv":
+			err = ratings.WriteMatrixCSV(os.Stdout, matrix)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			err = encoder.Encode(matrix)
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, cmd.String("format"))
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to export head-to-head matrix: %w", err)
+		}
+
+		return nil
+	})
 }
 
 func main() {
@@ -135,14 +413,55 @@ func main() {
 						Value:   "./tmp",
 						Sources: cli.EnvVars("SHIKI_TMP_DIR"),
 					},
+					&cli.StringSliceFlag{
+						Name:    "trusted-proxies",
+						Usage:   "IPs or CIDR ranges of reverse proxies whose X-Forwarded-For is trusted",
+						Sources: cli.EnvVars("SHIKI_TRUSTED_PROXIES"),
+					},
 					&cli.StringFlag{
 						Name:    "signature-secret",
 						Value:   "secret",
 						Sources: cli.EnvVars("SHIKI_SIGNATURE_SECRET"),
 					},
+					&cli.StringFlag{
+						Name:    "admin-token",
+						Usage:   "bearer token with the admin scope in addition to the API keys",
+						Sources: cli.EnvVars("SHIKI_ADMIN_TOKEN"),
+					},
+					&cli.BoolFlag{
+						Name:    "anonymous-voting",
+						Value:   true,
+						Usage:   "let voters use the matchmaker without an API key",
+						Sources: cli.EnvVars("SHIKI_ANONYMOUS_VOTING"),
+					},
+					&cli.BoolFlag{
+						Name:    "public-ratings",
+						Value:   true,
+						Usage:   "let anyone read ratings and contests without an API key",
+						Sources: cli.EnvVars("SHIKI_PUBLIC_RATINGS"),
+					},
+					&cli.StringFlag{
+						Name:    "oidc-issuer",
+						Usage:   "OpenID Connect issuer URL to log voters in with, login is disabled when empty",
+						Sources: cli.EnvVars("SHIKI_OIDC_ISSUER"),
+					},
+					&cli.StringFlag{
+						Name:    "oidc-client-id",
+						Sources: cli.EnvVars("SHIKI_OIDC_CLIENT_ID"),
+					},
+					&cli.StringFlag{
+						Name:    "oidc-client-secret",
+						Sources: cli.EnvVars("SHIKI_OIDC_CLIENT_SECRET"),
+					},
+					&cli.StringFlag{
+						Name:    "oidc-redirect-url",
+						Usage:   "public URL of /auth/callback, e.g. https://shiki.example.com/auth/callback",
+						Sources: cli.EnvVars("SHIKI_OIDC_REDIRECT_URL"),
+					},
 					&cli.IntFlag{
 						Name:    "base-difficulty",
 						Value:   0,
+						Usage:   "not applied yet, match-ups of the default contest ask for no proof of work",
 						Sources: cli.EnvVars("SHIKI_BASE_DIFFICULTY"),
 					},
 					&cli.IntFlag{
@@ -150,18 +469,474 @@ func main() {
 						Value:   3,
 						Sources: cli.EnvVars("SHIKI_OPPONENTS"),
 					},
+					&cli.StringSliceFlag{
+						Name:    "criteria",
+						Usage:   "judging criteria of the default contest as <id>=<prompt>",
+						Sources: cli.EnvVars("SHIKI_CRITERIA"),
+					},
 					&cli.IntFlag{
 						Name:    "token-max-age-minutes",
 						Value:   5,
 						Sources: cli.EnvVars("SHIKI_TOKEN_MAX_AGE_MINUTES"),
 					},
+					&cli.Float64Flag{
+						Name:    "gold-rate",
+						Usage:   "share of match-ups served from the gold pairs",
+						Sources: cli.EnvVars("SHIKI_GOLD_RATE"),
+					},
+					&cli.IntFlag{
+						Name:    "gold-min-answers",
+						Usage:   "gold answers before a voter's accuracy is judged, 0 to never judge",
+						Sources: cli.EnvVars("SHIKI_GOLD_MIN_ANSWERS"),
+					},
+					&cli.Float64Flag{
+						Name:    "gold-min-accuracy",
+						Value:   0.7, //nolint:mnd
+						Usage:   "gold accuracy below which a voter's votes are discounted or quarantined",
+						Sources: cli.EnvVars("SHIKI_GOLD_MIN_ACCURACY"),
+					},
+					&cli.StringFlag{
+						Name:    "gold-action",
+						Value:   string(scorer.GoldActionDiscount),
+						Usage:   "discount or quarantine",
+						Sources: cli.EnvVars("SHIKI_GOLD_ACTION"),
+					},
+					&cli.StringFlag{
+						Name:    "rate-limit-config",
+						Usage:   "JSON file with the match_up, outcome and upload rate limits",
+						Sources: cli.EnvVars("SHIKI_RATE_LIMIT_CONFIG"),
+					},
+					&cli.StringFlag{
+						Name:    "rate-limit-match-up",
+						Usage:   "limit of GET match-up as <requests>/<s|m|h>[,burst=<n>][,key=<ip|voter|key>]",
+						Sources: cli.EnvVars("SHIKI_RATE_LIMIT_MATCH_UP"),
+					},
+					&cli.StringFlag{
+						Name:    "rate-limit-outcome",
+						Usage:   "limit of POST outcome, in the same format",
+						Sources: cli.EnvVars("SHIKI_RATE_LIMIT_OUTCOME"),
+					},
+					&cli.StringFlag{
+						Name:    "rate-limit-upload",
+						Usage:   "limit of POST upload, in the same format",
+						Sources: cli.EnvVars("SHIKI_RATE_LIMIT_UPLOAD"),
+					},
+					&cli.BoolFlag{
+						Name:    "position-correction",
+						Usage:   "remove the measured advantage of the winner's slot from rating updates",
+						Sources: cli.EnvVars("SHIKI_POSITION_CORRECTION"),
+					},
+					&cli.IntFlag{
+						Name:    "min-deliberation-ms",
+						Usage:   "reject outcomes posted sooner after their match-up was issued",
+						Sources: cli.EnvVars("SHIKI_MIN_DELIBERATION_MS"),
+					},
+					&cli.IntFlag{
+						Name:    "timing-min-mean-ms",
+						Usage:   "flag voters whose recent votes took less on average, 0 to disable",
+						Sources: cli.EnvVars("SHIKI_TIMING_MIN_MEAN_MS"),
+					},
+					&cli.Float64Flag{
+						Name:    "timing-min-cv",
+						Usage:   "flag voters whose recent vote times vary less than this coefficient of variation, 0 to disable",
+						Sources: cli.EnvVars("SHIKI_TIMING_MIN_CV"),
+					},
+					&cli.StringFlag{
+						Name:    "timing-action",
+						Value:   string(voter.TimingActionLog),
+						Usage:   "reject, discount or log the votes of flagged voters",
+						Sources: cli.EnvVars("SHIKI_TIMING_ACTION"),
+					},
+					&cli.IntFlag{
+						Name:    "scorer-batch-max-size",
+						Value:   256,
+						Sources: cli.EnvVars("SHIKI_SCORER_BATCH_MAX_SIZE"),
+					},
+					&cli.IntFlag{
+						Name:    "scorer-batch-max-latency-ms",
+						Value:   50,
+						Sources: cli.EnvVars("SHIKI_SCORER_BATCH_MAX_LATENCY_MS"),
+					},
+					&cli.IntFlag{
+						Name:    "queue-capacity",
+						Value:   100000,
+						Sources: cli.EnvVars("SHIKI_QUEUE_CAPACITY"),
+					},
+					&cli.IntFlag{
+						Name:    "snapshot-interval-seconds",
+						Value:   60,
+						Usage:   "write a snapshot for read-only tools this often, 0 to disable",
+						Sources: cli.EnvVars("SHIKI_SNAPSHOT_INTERVAL_SECONDS"),
+					},
+					&cli.IntFlag{
+						Name:    "shutdown-timeout-seconds",
+						Value:   30,
+						Sources: cli.EnvVars("SHIKI_SHUTDOWN_TIMEOUT_SECONDS"),
+					},
 				},
 				Action: runServer,
 			},
 			{
-				Name:   "list-ratings",
+				Name:  "list-ratings",
+				Usage: "list the ratings, from the latest snapshot while the server is running",
+				Flags: []cli.Flag{
+					contestFlag(),
+					criterionFlag(),
+					&cli.StringFlag{
+						Name:  "sort",
+						Value: "rating",
+						Usage: "rating or games",
+					},
+					&cli.IntFlag{
+						Name:  "top",
+						Usage: "list only the first N assets, 0 for all",
+					},
+					&cli.Int64Flag{
+						Name:  "min-games",
+						Usage: "list only assets with at least this many games",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "table",
+						Usage: "table, json, csv or ndjson",
+					},
+				},
 				Action: listRatings,
 			},
+			{
+				Name:      "rating-history",
+				ArgsUsage: "<asset-id>...",
+				Flags: []cli.Flag{
+					contestFlag(),
+					criterionFlag(),
+					&cli.Int64Flag{
+						Name:  "interval",
+						Usage: "keep only the last entry per interval of seconds",
+					},
+					&cli.IntFlag{
+						Name:  "max-points",
+						Usage: "keep at most this many evenly spaced entries",
+					},
+				},
+				Action: ratingHistory,
+			},
+			{
+				Name:      "head-to-head",
+				ArgsUsage: "<asset-id>...",
+				Flags: []cli.Flag{
+					contestFlag(),
+					criterionFlag(),
+				},
+				Action: headToHead,
+			},
+			{
+				Name: "contests",
+				Commands: []*cli.Command{
+					{
+						Name:      "create",
+						Usage:     "create a contest with its own assets and ratings",
+						ArgsUsage: "<contest-id>",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:  "name",
+								Usage: "display name, defaults to the contest ID",
+							},
+							&cli.StringFlag{
+								Name:     "assets",
+								Required: true,
+								Usage:    "file with one asset ID per line, - for stdin",
+							},
+							&cli.IntFlag{
+								Name:  "opponents",
+								Value: 3,
+							},
+							&cli.IntFlag{
+								Name:  "difficulty",
+								Value: 0,
+							},
+							&cli.StringSliceFlag{
+								Name:  "criterion",
+								Usage: "judging criterion as <id>=<prompt>, can be repeated",
+							},
+						}, serverFlags()...),
+						Action: createContest,
+					},
+					{
+						Name:  "list",
+						Usage: "list the contests",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						},
+						Action: listContests,
+					},
+				},
+			},
+			{
+				Name: "keys",
+				Commands: []*cli.Command{
+					{
+						Name:  "create",
+						Usage: "create an API key and print its token",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:     "name",
+								Required: true,
+								Usage:    "who or what the key is for",
+							},
+							&cli.StringSliceFlag{
+								Name:     "scope",
+								Required: true,
+								Usage:    "upload, admin or read-only, can be repeated",
+							},
+						}, serverFlags()...),
+						Action: createKey,
+					},
+					{
+						Name:  "list",
+						Usage: "list the API keys",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						}, serverFlags()...),
+						Action: listKeys,
+					},
+					{
+						Name:      "revoke",
+						Usage:     "revoke an API key",
+						ArgsUsage: "<key-id>",
+						Flags:     serverFlags(),
+						Action:    revokeKey,
+					},
+				},
+			},
+			{
+				Name:  "gold",
+				Usage: "manage the gold pairs voters are graded on",
+				Commands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "add a match-up with a known winner",
+						ArgsUsage: "<asset-id>...",
+						Flags: append([]cli.Flag{
+							contestFlag(),
+							criterionFlag(),
+							&cli.StringFlag{
+								Name:     "winner",
+								Required: true,
+								Usage:    "asset ID that has to win",
+							},
+							&cli.IntFlag{
+								Name:    "opponents",
+								Value:   3,
+								Usage:   "opponents per match-up of the default contest, as given to the server; the server knows them with --server",
+								Sources: cli.EnvVars("SHIKI_OPPONENTS"),
+							},
+							&cli.StringSliceFlag{
+								Name:    "criteria",
+								Usage:   "criteria of the default contest, as given to the server; the server knows them with --server",
+								Sources: cli.EnvVars("SHIKI_CRITERIA"),
+							},
+						}, serverFlags()...),
+						Action: addGold,
+					},
+					{
+						Name:  "list",
+						Usage: "list the gold pairs",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						},
+						Action: listGold,
+					},
+					{
+						Name:      "remove",
+						Usage:     "remove a gold pair",
+						ArgsUsage: "<gold-id>",
+						Flags:     serverFlags(),
+						Action:    removeGold,
+					},
+				},
+			},
+			{
+				Name: "export-matrix",
+				Flags: []cli.Flag{
+					contestFlag(),
+					criterionFlag(),
+					&cli.IntFlag{
+						Name:  "top",
+						Value: 100,
+						Usage: "include the top N rated assets, 0 for all",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "csv",
+						Usage: "csv or json",
+					},
+				},
+				Action: exportMatrix,
+			},
+			{
+				Name:  "backup",
+				Usage: "write a consistent backup of the database",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "output",
+						Aliases:  []string{"o"},
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "compression",
+						Value: backup.CompressionNone,
+						Usage: "none or gzip; zstd isn't supported",
+					},
+					&cli.StringFlag{
+						Name:  "server",
+						Usage: "download the backup from a running server, e.g. http://localhost:3000",
+					},
+					&cli.StringFlag{
+						Name:    "token",
+						Usage:   "admin token or API key with the admin scope",
+						Sources: cli.EnvVars("SHIKI_ADMIN_TOKEN"),
+					},
+				},
+				Action: backupDatabase,
+			},
+			{
+				Name:      "restore",
+				Usage:     "verify a backup and swap it in as the database",
+				ArgsUsage: "<backup-file>",
+				Action:    restoreDatabase,
+			},
+			{
+				Name: "analyze",
+				Commands: []*cli.Command{
+					{
+						Name:  "cycles",
+						Usage: "find preference cycles in the head-to-head records",
+						Flags: []cli.Flag{
+							contestFlag(),
+							criterionFlag(),
+							&cli.IntFlag{
+								Name:  "top",
+								Value: 20,
+								Usage: "number of most-violated triads to report",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						},
+						Action: analyzeCycles,
+					},
+					{
+						Name:  "bootstrap",
+						Usage: "estimate confidence intervals of ratings and ranks",
+						Flags: []cli.Flag{
+							contestFlag(),
+							criterionFlag(),
+							&cli.IntFlag{
+								Name:  "replicates",
+								Value: 1000,
+								Usage: "number of bootstrap resamples",
+							},
+							&cli.Uint64Flag{
+								Name:  "seed",
+								Usage: "seed of the resampling, for reproducible results",
+							},
+							&cli.IntFlag{
+								Name:  "top",
+								Usage: "report only the top N assets, 0 for all",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						},
+						Action: analyzeBootstrap,
+					},
+					{
+						Name:  "crowd-bt",
+						Usage: "rank assets by stored ballots, weighted by estimated voter reliability",
+						Flags: []cli.Flag{
+							contestFlag(),
+							criterionFlag(),
+							&cli.IntFlag{
+								Name:  "top",
+								Usage: "report only the top N assets, 0 for all",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						},
+						Action: analyzeCrowd,
+					},
+					{
+						Name:  "position-bias",
+						Usage: "report how often each slot of a match-up wins",
+						Flags: []cli.Flag{
+							contestFlag(),
+							criterionFlag(),
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						},
+						Action: analyzePositions,
+					},
+				},
+			},
+			{
+				Name: "db",
+				Commands: []*cli.Command{
+					{
+						Name:  "migrate",
+						Usage: "apply pending schema migrations",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "only list pending migrations",
+							},
+						},
+						Action: migrateDatabase,
+					},
+					{
+						Name:  "check",
+						Usage: "validate the database and optionally repair it",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "repair",
+								Usage: "fix what can be fixed",
+							},
+							&cli.Float64Flag{
+								Name:  "tolerance",
+								Value: integrity.DefaultTolerance,
+								Usage: "allowed drift of the mean rating from 1500",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "table",
+								Usage: "table or json",
+							},
+						},
+						Action: checkDatabase,
+					},
+				},
+			},
 		},
 		DefaultCommand: "server",
 	}
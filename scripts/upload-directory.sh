@@ -10,6 +10,11 @@ fi
 DIR="${1}"
 URL="${2:-http://localhost:3000/api/receiver/upload}"
 
+if [ -z "${SHIKI_TOKEN:-}" ]; then
+    echo "Error: SHIKI_TOKEN must be set to a key with the upload scope" >&2
+    exit 1
+fi
+
 if [ ! -d "${DIR}" ]; then
     echo "Error: directory does not exist: ${DIR}" >&2
     exit 1
@@ -28,7 +33,7 @@ echo "Found ${#FILES[@]} files in ${DIR}"
 for FILE in "${FILES[@]}"; do
     if [ -f "${FILE}" ]; then
         echo "Uploading: ${FILE}"
-        curl -X POST -F "files=@${FILE}" "${URL}" || {
+        curl -X POST -H "Authorization: Bearer ${SHIKI_TOKEN}" -F "files=@${FILE}" "${URL}" || {
             echo "Failed to upload ${FILE}" >&2
             exit 1
         }